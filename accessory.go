@@ -0,0 +1,187 @@
+package main
+
+import "fmt"
+
+// AccessoryEffect is the passive bonus a ring or pendant gives while worn
+type AccessoryEffect int
+
+const (
+	EffectMaxHealth AccessoryEffect = iota
+	EffectAttack
+	EffectRegen
+	EffectCrit
+	EffectLight
+)
+
+// Describe explains what an accessory with this effect and strength does
+func (e AccessoryEffect) Describe(value int) string {
+	switch e {
+	case EffectMaxHealth:
+		return fmt.Sprintf("%+d max health", value)
+	case EffectAttack:
+		return fmt.Sprintf("%+d attack", value)
+	case EffectRegen:
+		return fmt.Sprintf("%+d regeneration", value)
+	case EffectCrit:
+		return fmt.Sprintf("%+d%% critical hit chance", value)
+	case EffectLight:
+		return fmt.Sprintf("%+d light radius", value)
+	default:
+		return "no effect"
+	}
+}
+
+// AccessorySlot is where a ring or pendant is worn
+type AccessorySlot int
+
+const (
+	SlotLeftRing AccessorySlot = iota
+	SlotRightRing
+	SlotNeck
+)
+
+// accessorySlots lists every slot in the order they are displayed
+var accessorySlots = []AccessorySlot{SlotLeftRing, SlotRightRing, SlotNeck}
+
+// Name returns a human-readable name for the slot
+func (s AccessorySlot) Name() string {
+	switch s {
+	case SlotLeftRing:
+		return "Left hand"
+	case SlotRightRing:
+		return "Right hand"
+	case SlotNeck:
+		return "Neck"
+	default:
+		return "Unknown"
+	}
+}
+
+// accessoryType describes a kind of ring or pendant
+type accessoryType struct {
+	name   string
+	effect AccessoryEffect
+	value  int
+}
+
+// ringTypes and pendantTypes list the accessories that can be found in the dungeon
+var (
+	ringTypes = []accessoryType{
+		{"Ring of Strength", EffectAttack, 1},
+		{"Ring of Regeneration", EffectRegen, 2},
+		{"Ring of Precision", EffectCrit, 15},
+	}
+	pendantTypes = []accessoryType{
+		{"Pendant of Vitality", EffectMaxHealth, 5},
+		{"Pendant of Radiance", EffectLight, 2},
+	}
+)
+
+// NewRing creates a new ring of the given kind
+func NewRing(x, y int, kind accessoryType) Item {
+	return Item{
+		X:           x,
+		Y:           y,
+		Type:        ItemRing,
+		Name:        kind.name,
+		Description: kind.effect.Describe(kind.value),
+		Value:       kind.value,
+		Effect:      kind.effect,
+		Symbol:      '(',
+		Weight:      0,
+	}
+}
+
+// NewPendant creates a new pendant of the given kind
+func NewPendant(x, y int, kind accessoryType) Item {
+	return Item{
+		X:           x,
+		Y:           y,
+		Type:        ItemPendant,
+		Name:        kind.name,
+		Description: kind.effect.Describe(kind.value),
+		Value:       kind.value,
+		Effect:      kind.effect,
+		Symbol:      '*',
+		Weight:      1,
+	}
+}
+
+// WearAccessory puts on a ring or pendant from the inventory. Rings go on a
+// free hand, or replace the left ring if both are taken (the right one if
+// the left is cursed); a pendant replaces any pendant already worn.
+func (p *Player) WearAccessory(itemIndex int) {
+	item := p.Inventory[itemIndex]
+	slot := SlotNeck
+	if item.Type == ItemRing {
+		slot = SlotLeftRing
+		if left, ok := p.Accessories[SlotLeftRing]; ok {
+			if _, ok := p.Accessories[SlotRightRing]; !ok || left.Cursed {
+				slot = SlotRightRing
+			}
+		}
+	}
+	if old, ok := p.Accessories[slot]; ok && old.Cursed {
+		p.Messages.Log("You can't take off the %s - it is cursed!", old.Name)
+		return
+	}
+
+	p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+	if old, ok := p.Accessories[slot]; ok {
+		p.applyAccessory(old, -1)
+		p.Inventory = append(p.Inventory, old)
+		p.Messages.Log("You take off the %s.", old.Name)
+	}
+	p.applyAccessory(item, 1)
+	p.Messages.Log("You put on the %s (%s).", item.Name, item.Description)
+	if item.Cursed {
+		item.Description = cursedDescription
+		p.Messages.Log("The %s tightens painfully around you. It is cursed!", item.Name)
+	}
+	p.Accessories[slot] = item
+}
+
+// applyAccessory adds an accessory's bonus to the player's stats, or with a
+// sign of -1 takes it away again
+func (p *Player) applyAccessory(item Item, sign int) {
+	delta := sign * item.Value
+	switch item.Effect {
+	case EffectMaxHealth:
+		p.MaxHealth += delta
+		if p.Health > p.MaxHealth {
+			p.Health = p.MaxHealth
+		}
+	case EffectAttack:
+		p.Attack += delta
+	case EffectRegen:
+		p.RegenBonus += delta
+	case EffectCrit:
+		p.CritChance += delta
+	case EffectLight:
+		p.LightRadius += delta
+	}
+}
+
+// accessoryBonus returns the total bonus of the given effect from worn accessories
+func (p *Player) accessoryBonus(effect AccessoryEffect) int {
+	total := 0
+	for _, item := range p.Accessories {
+		if item.Effect == effect {
+			total += item.Value
+		}
+	}
+	return total
+}
+
+// AccessoryLines describes what is worn in each accessory slot
+func (p *Player) AccessoryLines() []string {
+	lines := make([]string, 0, len(accessorySlots))
+	for _, slot := range accessorySlots {
+		if item, ok := p.Accessories[slot]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s (%s)", slot.Name(), item.Name, item.Description))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: nothing", slot.Name()))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,285 @@
+package main
+
+import "sort"
+
+// EnemyBehavior decides what an enemy does on its turn
+type EnemyBehavior interface {
+	TakeTurn(e *Enemy, d *Dungeon, p *Player)
+}
+
+// Walker is implemented by enemy behaviors with their own idea of which
+// tiles they can move onto, in place of IsWalkable
+type Walker interface {
+	CanWalk(d *Dungeon, x, y int) bool
+}
+
+// MeleeBehavior chases the player when nearby and wanders otherwise
+type MeleeBehavior struct{}
+
+// TakeTurn moves toward the player if they are close, or wanders randomly
+func (MeleeBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	// If player is close (within 5 tiles) or the enemy was alerted, move toward them
+	if e.Hostile && e.notices(p, 5) {
+		d.stepEnemy(e, toward(e.X, e.Y, p.X, p.Y), p)
+		return
+	}
+	wander(e, d, p)
+}
+
+// RangedBehavior keeps its distance and fires at the player from afar
+type RangedBehavior struct {
+	Range int // Maximum firing distance
+}
+
+// TakeTurn backs away from a close player, shoots when in range, and otherwise wanders
+func (b RangedBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	dist := distance(e.X, e.Y, p.X, p.Y)
+	if !e.Hostile || !e.notices(p, b.Range+1) {
+		wander(e, d, p)
+		return
+	}
+
+	// Too close for comfort: step back
+	if dist <= 2 {
+		d.stepEnemy(e, toward(e.X, e.Y, p.X, p.Y).Opposite(), p)
+		return
+	}
+
+	// Fire if in range and nothing stands in the way, otherwise close in
+	if dist <= b.Range && d.hasClearShot(e.X, e.Y, p.X, p.Y) {
+		e.AttackPlayer(p, d, "shoots at")
+		return
+	}
+	d.stepEnemy(e, toward(e.X, e.Y, p.X, p.Y), p)
+}
+
+// CowardBehavior fights like a melee enemy but runs away when badly hurt
+type CowardBehavior struct {
+	FleePercent int // Percentage of MaxHealth at or below which the enemy flees
+}
+
+// TakeTurn flees from the player when hurt, or acts like a melee enemy
+func (b CowardBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	if e.Health*100 <= e.MaxHealth*b.FleePercent && distance(e.X, e.Y, p.X, p.Y) < 5 {
+		d.stepEnemy(e, toward(e.X, e.Y, p.X, p.Y).Opposite(), p)
+		return
+	}
+	MeleeBehavior{}.TakeTurn(e, d, p)
+}
+
+// ThiefBehavior sneaks up on the player, steals some gold, and then runs for
+// the stairs to escape with it
+type ThiefBehavior struct{}
+
+// TakeTurn steals from an adjacent player, flees with the loot, or else
+// closes in like a melee enemy
+func (ThiefBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	if e.StolenGold > 0 {
+		// Slip away down the stairs, or head for them
+		x, y, ok := d.StairsPos()
+		if ok && e.X == x && e.Y == y {
+			d.RemoveEnemy(e)
+			d.Messages.Log("%s escapes down the stairs with %d of your gold!", capitalize(e.Title()), e.StolenGold)
+			return
+		}
+		if ok {
+			d.stepEnemy(e, toward(e.X, e.Y, x, y), p)
+		} else {
+			d.stepEnemy(e, toward(e.X, e.Y, p.X, p.Y).Opposite(), p)
+		}
+		return
+	}
+	
+	// Pick the player's pocket when close enough
+	if e.Hostile && p.Gold > 0 && distance(e.X, e.Y, p.X, p.Y) == 1 {
+		stolen := 5 + d.Rng.Intn(16)
+		if stolen > p.Gold {
+			stolen = p.Gold
+		}
+		p.Gold -= stolen
+		e.StolenGold = stolen
+		d.Messages.Log("%s snatches %d gold from you and runs!", capitalize(e.Title()), stolen)
+		return
+	}
+	MeleeBehavior{}.TakeTurn(e, d, p)
+}
+
+// MimicBehavior lies in wait disguised as treasure, then fights like a
+// melee enemy once found out
+type MimicBehavior struct{}
+
+// TakeTurn springs the ambush on an adjacent player, waits while disguised,
+// or acts like a melee enemy once revealed
+func (MimicBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	if !e.Disguised {
+		MeleeBehavior{}.TakeTurn(e, d, p)
+		return
+	}
+	if distance(e.X, e.Y, p.X, p.Y) == 1 {
+		d.Unmask(e)
+		e.AttackPlayer(p, d, "lunges at")
+	}
+}
+
+// Unmask reveals a disguised mimic, clearing the fake treasure under it
+func (d *Dungeon) Unmask(e *Enemy) {
+	if !e.Disguised {
+		return
+	}
+	e.Disguised = false
+	d.set(e.X, e.Y, rune(Floor))
+	d.Messages.Log("The treasure sprouts teeth - it's a %s!", e.Name)
+}
+
+// RallyBehavior fights like a melee enemy, but on spotting the player may
+// call for help, summoning goblins from nearby rooms
+type RallyBehavior struct {
+	Chance   int // Percentage chance of calling for help on spotting the player
+	Cooldown int // Turns before the enemy can call for help again
+}
+
+// TakeTurn calls for help when the player first comes into view, or acts
+// like a melee enemy
+func (b RallyBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	if e.Cooldown > 0 {
+		e.Cooldown--
+	}
+	sees := e.Hostile && e.notices(p, 5)
+	spotted := sees && !e.Spotted
+	e.Spotted = sees
+	if spotted && e.Cooldown == 0 && d.Rng.Intn(100) < b.Chance {
+		e.Cooldown = b.Cooldown
+		d.CallForHelp(e, p)
+		return
+	}
+	MeleeBehavior{}.TakeTurn(e, d, p)
+}
+
+// CallForHelp summons one or two goblins into the rooms nearest the caller,
+// already hunting the player
+func (d *Dungeon) CallForHelp(e *Enemy, p *Player) {
+	d.Messages.Log("%s bellows a call for help!", capitalize(e.Title()))
+	
+	// The closest rooms other than the caller's own send the help
+	own := d.GetRoomAt(e.X, e.Y)
+	var rooms []*Room
+	for i := range d.Rooms {
+		if room := &d.Rooms[i]; room != own {
+			rooms = append(rooms, room)
+		}
+	}
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return distance(e.X, e.Y, rooms[i].X+rooms[i].Width/2, rooms[i].Y+rooms[i].Height/2) <
+			distance(e.X, e.Y, rooms[j].X+rooms[j].Width/2, rooms[j].Y+rooms[j].Height/2)
+	})
+	if len(rooms) > 2 {
+		rooms = rooms[:2]
+	}
+	
+	arrived := 0
+	for i := 1 + d.Rng.Intn(2); i > 0 && len(rooms) > 0; i-- {
+		x, y, ok := d.freeTileIn(*rooms[d.Rng.Intn(len(rooms))], p)
+		if !ok {
+			continue
+		}
+		goblin := enemyTypes["Goblin"].spawn(x, y)
+		goblin.Alerted = true
+		d.AddEnemy(goblin)
+		arrived++
+	}
+	if arrived > 0 {
+		d.Messages.Log("You hear footsteps answering the call.")
+	}
+}
+
+// freeTileIn picks a random floor tile in room that is free of enemies,
+// traps, and the player, returning false if none turned up
+func (d *Dungeon) freeTileIn(room Room, p *Player) (int, int, bool) {
+	for attempts := 0; attempts < 20; attempts++ {
+		x := room.X + d.Rng.Intn(room.Width)
+		y := room.Y + d.Rng.Intn(room.Height)
+		if d.at(x, y) != rune(Floor) || d.GetEnemyAt(x, y) != nil || d.GetTrapAt(x, y) != nil {
+			continue
+		}
+		if x == p.X && y == p.Y {
+			continue
+		}
+		return x, y, true
+	}
+	return 0, 0, false
+}
+
+// GhostBehavior drifts through walls, chasing the player like a melee enemy
+type GhostBehavior struct{}
+
+// TakeTurn acts like a melee enemy, but stepEnemy lets it pass through walls
+func (GhostBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	MeleeBehavior{}.TakeTurn(e, d, p)
+}
+
+// CanWalk allows any tile inside the map, walls included
+func (GhostBehavior) CanWalk(d *Dungeon, x, y int) bool {
+	return d.inBounds(x, y)
+}
+
+// BatBehavior flutters about erratically, diagonals included, drifting
+// toward a nearby player
+type BatBehavior struct{}
+
+// TakeTurn flies one tile in a random direction, or half the time toward a
+// player within a few tiles
+func (BatBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	dir := allDirections[d.Rng.Intn(len(allDirections))]
+	if e.Hostile && e.notices(p, 6) && d.Rng.Intn(2) == 0 {
+		dir, _ = facing(sign(p.X-e.X), sign(p.Y-e.Y))
+	}
+	d.stepEnemy(e, dir, p)
+}
+
+// sign returns -1, 0, or 1 matching the sign of x
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// StationaryBehavior never moves
+type StationaryBehavior struct{}
+
+// TakeTurn does nothing
+func (StationaryBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {}
+
+// wander moves the enemy in a random direction
+func wander(e *Enemy, d *Dungeon, p *Player) {
+	if d.Rng.Intn(3) > 0 { // 2/3 chance to move
+		d.stepEnemy(e, orthogonalDirections[d.Rng.Intn(len(orthogonalDirections))], p)
+	}
+}
+
+// distance returns the Manhattan distance between two points
+func distance(x1, y1, x2, y2 int) int {
+	return abs(x2-x1) + abs(y2-y1)
+}
+
+// toward returns the orthogonal direction of a single step from (x1, y1) toward (x2, y2)
+func toward(x1, y1, x2, y2 int) Direction {
+	distX := x2 - x1
+	distY := y2 - y1
+	if abs(distX) > abs(distY) {
+		// Move horizontally
+		if distX > 0 {
+			return East
+		}
+		return West
+	}
+
+	// Move vertically
+	if distY > 0 {
+		return South
+	}
+	return North
+}
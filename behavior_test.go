@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestThiefEscapeKeepsOtherTurns(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.clearEnemies()
+	p := newPlayerIn(d, ClassWarrior)
+	x, y, ok := d.StairsPos()
+	if !ok {
+		t.Fatal("level has no stairs")
+	}
+
+	thief := enemyTypes["Thief"].spawn(x, y)
+	thief.StolenGold = 10
+	thief.Fast = true
+	d.AddEnemy(thief)
+	turns := make(map[*Enemy]int)
+	rat := &Enemy{Name: "Rat", X: 1, Y: 1, Health: 5, Behavior: countingBehavior{turns, false}}
+	d.AddEnemy(rat)
+
+	d.Messages = NewMessageLog(10)
+	d.MoveEnemies(p)
+	if d.present(thief) {
+		t.Fatal("the thief is still on the level")
+	}
+	if turns[rat] != 1 {
+		t.Errorf("rat took %d turns after the thief escaped, want 1", turns[rat])
+	}
+	escapes := 0
+	for _, msg := range d.Messages.Last(10) {
+		if strings.Contains(msg, "escapes down the stairs") {
+			escapes++
+		}
+	}
+	if escapes != 1 {
+		t.Errorf("the thief escaped %d times, want 1", escapes)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+const (
+	bombDamage = 8 // Damage a bomb deals to everything caught in the blast
+	bombRadius = 1 // How far the blast reaches, diagonals included
+)
+
+// NewBomb creates a new bomb, which explodes where it lands when thrown
+func NewBomb(x, y int) Item {
+	return Item{
+		X:           x,
+		Y:           y,
+		Type:        ItemBomb,
+		Name:        "Bomb",
+		Description: "Explodes where it lands when thrown, dealing 8 damage to everything next to it",
+		Value:       bombDamage,
+		Symbol:      '`',
+		Weight:      2,
+	}
+}
+
+// addBomb has a 20% chance of placing a bomb
+func (d *Dungeon) addBomb() {
+	if d.Rng.Intn(100) >= 20 {
+		return
+	}
+
+	room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) == rune(Floor) && d.GetItemAt(x, y) == nil {
+		d.AddItem(NewBomb(x, y))
+	}
+}
+
+// inBlast reports whether (x, y) is close enough to a blast at (bx, by) to be hit
+func inBlast(x, y, bx, by int) bool {
+	return max(abs(x-bx), abs(y-by)) <= bombRadius
+}
+
+// explode sets off a thrown bomb at (x, y), hurting every hostile enemy
+// around it, and the player too if they are standing that close
+func (p *Player) explode(bomb Item, x, y int, d *Dungeon) {
+	p.Messages.Log("The %s explodes!", bomb.Name)
+	d.MakeNoise(x, y, explosionNoise)
+
+	// Defeated enemies leave the list, so go through a copy
+	for _, enemy := range append([]*Enemy(nil), d.Enemies...) {
+		if enemy.Health <= 0 || !enemy.Hostile || !inBlast(enemy.X, enemy.Y, x, y) {
+			continue
+		}
+		damage := p.DealDamage(enemy, bomb.Value, bomb.Element, d)
+		p.Messages.Log("The blast hits %s for %d damage!", enemy.Title(), damage)
+		if enemy.Health <= 0 {
+			p.DefeatEnemy(enemy, d)
+		}
+	}
+
+	if inBlast(p.X, p.Y, x, y) {
+		damage := resist(bomb.Value, bomb.Element, p.Resistances)
+		p.TakeDamage(damage)
+		p.Messages.Log("You are caught in the blast for %d damage!", damage)
+		if p.Health <= 0 {
+			p.Messages.Log("You blew yourself up! Game over.")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+// DeathBehavior is implemented by enemy behaviors that do something when
+// the enemy is slain
+type DeathBehavior interface {
+	OnDeath(e *Enemy, d *Dungeon)
+}
+
+// maxReassembles is how many times a skeleton can pull itself back together
+const maxReassembles = 2
+
+// Bones are the remains of a skeleton, waiting to reassemble
+type Bones struct {
+	X, Y        int
+	Turns       int // Turns left before the bones get back up
+	Reassembled int // Times the skeleton has already been rebuilt
+}
+
+// BoneBehavior fights like a ranged enemy, and when slain may leave bones
+// that reassemble into a weaker skeleton a few turns later
+type BoneBehavior struct {
+	RangedBehavior
+	Chance int // Percentage chance of leaving bones that reassemble
+}
+
+// OnDeath leaves a pile of bones behind, unless the skeleton has already
+// been rebuilt too many times
+func (b BoneBehavior) OnDeath(e *Enemy, d *Dungeon) {
+	if e.Boss || e.Reassembled >= maxReassembles || d.Rng.Intn(100) >= b.Chance {
+		return
+	}
+	d.Bones = append(d.Bones, Bones{X: e.X, Y: e.Y, Turns: 3 + d.Rng.Intn(3), Reassembled: e.Reassembled})
+	d.Messages.Log("%s collapses into a pile of twitching bones.", capitalize(e.Title()))
+}
+
+// UpdateBones counts down every pile of bones, reassembling those whose time
+// has come. Bones that find their tile occupied crumble to dust instead.
+func (d *Dungeon) UpdateBones(p *Player) {
+	remaining := d.Bones[:0]
+	for _, bones := range d.Bones {
+		bones.Turns--
+		if bones.Turns > 0 {
+			remaining = append(remaining, bones)
+			continue
+		}
+		if d.GetEnemyAt(bones.X, bones.Y) != nil || (p.X == bones.X && p.Y == bones.Y) {
+			continue
+		}
+
+		// Each time it comes back a little weaker
+		skeleton := enemyTypes["Skeleton"].spawn(bones.X, bones.Y)
+		skeleton.Reassembled = bones.Reassembled + 1
+		skeleton.MaxHealth = max(skeleton.MaxHealth/(skeleton.Reassembled+1), 1)
+		skeleton.Health = skeleton.MaxHealth
+		skeleton.Damage = max(skeleton.Damage-skeleton.Reassembled, 1)
+		d.AddEnemy(skeleton)
+		d.Messages.Log("The bones rattle and pull themselves back together!")
+	}
+	d.Bones = remaining
+}
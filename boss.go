@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// Syllables and epithets that boss names are built from
+var (
+	bossNameStarts  = []string{"Gruk", "Mor", "Zal", "Vex", "Thra", "Kul", "Ska", "Dro", "Ur", "Nag"}
+	bossNameMiddles = []string{"", "", "a", "o", "ith", "um", "ek"}
+	bossNameEnds    = []string{"thar", "gul", "rok", "zeth", "mar", "dush", "grim", "nak"}
+	bossEpithets    = []string{
+		"the Defiler", "the Hungering", "the Unbroken", "the Rotten",
+		"Bonegnawer", "the Cruel", "of the Deep", "Skullsplitter",
+	}
+)
+
+// Title returns how messages refer to the enemy: "the Orc", or a boss's own name
+func (e *Enemy) Title() string {
+	if e.Boss {
+		return e.Name
+	}
+	return "the " + e.Name
+}
+
+// capitalize upper-cases the first letter of a message fragment
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// bossName builds a proper name such as "Grukthar the Defiler". Drawing it
+// from the dungeon's rng means the same seed always names the same boss.
+func bossName(rng *rand.Rand) string {
+	name := bossNameStarts[rng.Intn(len(bossNameStarts))] +
+		bossNameMiddles[rng.Intn(len(bossNameMiddles))] +
+		bossNameEnds[rng.Intn(len(bossNameEnds))]
+	return name + " " + bossEpithets[rng.Intn(len(bossEpithets))]
+}
+
+// spawnBoss places a named, much stronger version of the theme's toughest
+// enemy next to (x, y)
+func (d *Dungeon) spawnBoss(x, y int) {
+	// The boss is the hardiest of the level's usual inhabitants
+	base := enemyTypes[d.Theme.Enemies[0].Name]
+	for _, entry := range d.Theme.Enemies[1:] {
+		if enemyTypes[entry.Name].health > base.health {
+			base = enemyTypes[entry.Name]
+		}
+	}
+
+	// Stand guard on the first free tile around the spot
+	for _, pos := range [][2]int{{x, y}, {x + 1, y}, {x - 1, y}, {x, y + 1}, {x, y - 1}} {
+		if !d.IsWalkable(pos[0], pos[1]) || d.GetEnemyAt(pos[0], pos[1]) != nil {
+			continue
+		}
+		boss := base.spawn(pos[0], pos[1])
+		boss.Name = bossName(d.Rng)
+		boss.MaxHealth *= 3
+		boss.Health = boss.MaxHealth
+		boss.Damage += 2
+		boss.Elite = true
+		boss.Boss = true
+		d.AddEnemy(boss)
+		return
+	}
+}
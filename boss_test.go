@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bossOf returns the boss set to guard the amulet on the level seed
+// generates at the winning depth
+func bossOf(t *testing.T, seed int64) *Enemy {
+	t.Helper()
+	cfg := DefaultConfig()
+	d := NewDungeon(cfg.WinLevel, rand.New(rand.NewSource(seed)), cfg)
+	d.addAmulet()
+	for _, enemy := range d.Enemies {
+		if enemy.Boss {
+			return enemy
+		}
+	}
+	t.Fatalf("seed %d put no boss on level %d", seed, cfg.WinLevel)
+	return nil
+}
+
+func TestBossNameFollowsSeed(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		first, second := bossOf(t, seed), bossOf(t, seed)
+		if first.Name != second.Name {
+			t.Errorf("seed %d named the boss %q, then %q", seed, first.Name, second.Name)
+		}
+	}
+
+	names := make(map[string]bool)
+	for seed := int64(1); seed <= 20; seed++ {
+		names[bossName(rand.New(rand.NewSource(seed)))] = true
+	}
+	if len(names) < 2 {
+		t.Errorf("20 seeds gave only the boss names %v", names)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+// addChests places up to two locked chests in rooms away from the start,
+// each with a key to open it lying in some other room
+func (d *Dungeon) addChests() {
+	if len(d.Rooms) < 3 {
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		// 35% chance for each chest
+		if d.Rng.Intn(100) >= 35 {
+			continue
+		}
+
+		roomIndex := 1 + d.Rng.Intn(len(d.Rooms)-1)
+		x, y, ok := d.emptyTileIn(d.Rooms[roomIndex])
+		if !ok {
+			continue
+		}
+		keyRoom := (roomIndex + 1 + d.Rng.Intn(len(d.Rooms)-1)) % len(d.Rooms)
+		keyX, keyY, ok := d.emptyTileIn(d.Rooms[keyRoom])
+		if !ok {
+			continue
+		}
+
+		d.set(x, y, rune(Chest))
+		d.Chests[[2]int{x, y}] = d.chestContents(x, y)
+		d.AddItem(NewKey(keyX, keyY))
+	}
+}
+
+// chestContents rolls what a chest at (x, y) holds: a better than common
+// weapon or piece of armor, or a pile of gold
+func (d *Dungeon) chestContents(x, y int) Item {
+	rarity := rollRarity(d.Rng, d.Level, Uncommon)
+	switch d.Rng.Intn(3) {
+	case 0:
+		kind := weaponTypes[d.Rng.Intn(len(weaponTypes))]
+		return NewWeapon(x, y, kind.name, kind.value, rarity)
+	case 1:
+		kind := armorTypes[d.Rng.Intn(len(armorTypes))]
+		return NewArmor(x, y, kind.name, kind.value, kind.slot, rarity)
+	default:
+		return NewGold(x, y, 50+d.Rng.Intn(51)+10*d.Level)
+	}
+}
+
+// OpenChest unlocks the chest at (x, y) with one of the player's keys,
+// leaving its contents on the floor where it stood
+func (p *Player) OpenChest(x, y int, d *Dungeon) {
+	keyIndex := -1
+	for i, item := range p.Inventory {
+		if item.Type == ItemKey {
+			keyIndex = i
+			break
+		}
+	}
+	if keyIndex < 0 {
+		p.Messages.Log("The chest is locked. You need a key to open it.")
+		return
+	}
+
+	// The key stays in the lock
+	p.Inventory = append(p.Inventory[:keyIndex], p.Inventory[keyIndex+1:]...)
+	contents := d.Chests[[2]int{x, y}]
+	delete(d.Chests, [2]int{x, y})
+	d.set(x, y, rune(Floor))
+	d.AddItem(contents)
+	p.Messages.Log("You unlock the chest with a key. Inside is %s.", p.Identities.Name(contents))
+}
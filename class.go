@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Class is the kind of adventurer the player starts the run as
+type Class int
+
+const (
+	ClassWarrior Class = iota
+	ClassMage
+	ClassRogue
+)
+
+// classInfo describes a character class
+type classInfo struct {
+	Name        string
+	Description string
+}
+
+// classes holds the details of every character class, in the order they are offered
+var classes = []classInfo{
+	ClassWarrior: {"Warrior", "Extra health and a short sword"},
+	ClassMage:    {"Mage", "Extra mana and the Heal spell"},
+	ClassRogue:   {"Rogue", "Dodges blows, carries a dagger, and sneaks at full speed"},
+}
+
+// Bonuses each class starts with
+const (
+	warriorHealth = 10 // Extra maximum health for warriors
+	mageMana      = 10 // Extra maximum mana for mages
+	rogueEvasion  = 15 // Percentage chance of a rogue dodging an enemy's attack
+)
+
+// String returns the class's name
+func (c Class) String() string {
+	return classes[c].Name
+}
+
+// parseClass reads a class by name or by its number in the list, 1 first
+func parseClass(text string) (Class, bool) {
+	text = strings.TrimSpace(text)
+	if n, err := strconv.Atoi(text); err == nil && n >= 1 && n <= len(classes) {
+		return Class(n - 1), true
+	}
+	for class, info := range classes {
+		if strings.EqualFold(text, info.Name) {
+			return Class(class), true
+		}
+	}
+	return 0, false
+}
+
+// applyClass gives a new player the stats and gear of their class
+func (p *Player) applyClass(class Class) {
+	p.Class = class
+	switch class {
+	case ClassWarrior:
+		p.MaxHealth += warriorHealth
+		p.Health = p.MaxHealth
+		p.wield(NewWeapon(0, 0, "Short Sword", 4, Common))
+
+	case ClassMage:
+		p.MaxMana += mageMana
+		p.Mana = p.MaxMana
+		p.Spells = append(p.Spells, SpellHeal)
+
+	case ClassRogue:
+		p.Evasion = rogueEvasion
+		p.wield(NewWeapon(0, 0, "Dagger", 3, Common))
+	}
+}
+
+// wield puts a starting weapon straight into the player's hand, adding its
+// bonus to the base attack
+func (p *Player) wield(weapon Item) {
+	weapon.Collected = true
+	p.Weapon = &weapon
+	p.Attack += weapon.Value
+	p.AttackType = weapon.Element
+}
+
+// sneaksFreely reports whether the player can sneak without slowing down
+func (p *Player) sneaksFreely() bool {
+	return p.Class == ClassRogue
+}
+
+// promptClass asks the player which class to play, defaulting to the first
+func (g *Game) promptClass() Class {
+	fmt.Fprintln(g.Out, "Choose your class:")
+	for i, info := range classes {
+		fmt.Fprintf(g.Out, "%d. %s - %s\n", i+1, info.Name, info.Description)
+	}
+	fmt.Fprint(g.Out, "Class (1-3, Enter for Warrior): ")
+	class, ok := parseClass(g.In.ReadLine())
+	if !ok {
+		class = ClassWarrior
+	}
+	return class
+}
+
+// ChooseClass makes the player a fresh character of the given class, for
+// this run and any restart
+func (g *Game) ChooseClass(class Class) {
+	g.Class = class
+	g.Player = NewPlayer(g.Player.X, g.Player.Y, g.Dungeon.Config, class)
+	g.Player.Name = g.Name
+	g.Player.Identities = g.Identities
+	g.Player.Messages = g.Messages
+	g.Messages.Log("You set out as a %s.", class)
+}
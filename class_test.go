@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestStartingWeaponAddsToBaseAttack(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StartAttack = 10
+	tests := []struct {
+		class Class
+		want  int
+	}{
+		{ClassWarrior, 14}, // Short sword
+		{ClassMage, 10},    // Bare hands
+		{ClassRogue, 13},   // Dagger
+	}
+	for _, tt := range tests {
+		if p := NewPlayer(0, 0, cfg, tt.class); p.Attack != tt.want {
+			t.Errorf("a %s starts with %d attack, want %d", tt.class, p.Attack, tt.want)
+		}
+	}
+}
+
+func TestSwappingWeaponsKeepsBaseAttack(t *testing.T) {
+	p := NewPlayer(0, 0, DefaultConfig(), ClassWarrior)
+	p.GainExp(p.ExpToLevel()) // Level up for +1 attack
+	before := p.Attack
+
+	p.Inventory = append(p.Inventory, NewWeapon(0, 0, "Longsword", 6, Common))
+	p.UseItem(len(p.Inventory)-1, nil)
+	if want := before - 4 + 6; p.Attack != want {
+		t.Errorf("attack = %d after swapping the short sword for a longsword, want %d", p.Attack, want)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+// strayDog is the stray that can be befriended as a companion
+var strayDog = enemyType{"Dog", 'd', 8, 2, CompanionBehavior{}, 0, Physical, nil, false}
+
+// addStray has a chance to place a stray dog in a room away from the start.
+// Strays are friendly and join the player once they meet.
+func (d *Dungeon) addStray() {
+	// 20% chance per level
+	if len(d.Rooms) <= 1 || d.Rng.Intn(100) >= 20 {
+		return
+	}
+
+	room := d.Rooms[1+d.Rng.Intn(len(d.Rooms)-1)]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) != rune(Floor) || d.GetEnemyAt(x, y) != nil {
+		return
+	}
+
+	dog := strayDog.spawn(x, y)
+	dog.Hostile = false
+	d.AddEnemy(dog)
+}
+
+// CompanionBehavior wanders as a stray until the player comes close, then
+// follows them and bites any hostile enemy beside it
+type CompanionBehavior struct{}
+
+// TakeTurn joins an adjacent player, attacks an adjacent enemy, or keeps up
+// with the player along the shortest path
+func (CompanionBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	if !e.Ally {
+		if distance(e.X, e.Y, p.X, p.Y) == 1 {
+			e.Ally = true
+			d.Messages.Log("The %s wags its tail and follows you.", e.Name)
+			return
+		}
+		wander(e, d, p)
+		return
+	}
+
+	// Fight whatever is next to it, once done looking, since a kill takes
+	// the enemy off the list
+	var target *Enemy
+	for _, enemy := range d.Enemies {
+		if enemy.Health > 0 && enemy.Hostile && !enemy.Disguised && distance(e.X, e.Y, enemy.X, enemy.Y) == 1 {
+			target = enemy
+			break
+		}
+	}
+	if target != nil {
+		damage := resist(rollDamage(d.Rng, e.Damage), e.AttackType, target.Resistances)
+		target.Health -= damage
+		d.Messages.Log("Your %s bites %s for %d damage!", e.Name, target.Title(), damage)
+		if target.Health <= 0 {
+			p.DefeatEnemy(target, d)
+		}
+		return
+	}
+
+	// Stay close to the player
+	if distance(e.X, e.Y, p.X, p.Y) > 2 {
+		if path := d.FindPath(e.X, e.Y, p.X, p.Y); len(path) > 1 {
+			if dir, ok := facing(path[0][0]-e.X, path[0][1]-e.Y); ok {
+				d.stepEnemy(e, dir, p)
+			}
+		}
+	}
+}
+
+// AttackAlly has a hostile enemy attack the player's companion, which runs
+// off for good if it dies
+func (e *Enemy) AttackAlly(ally *Enemy, d *Dungeon) {
+	damage := resist(rollDamage(d.Rng, e.Damage), e.AttackType, ally.Resistances)
+	ally.Health -= damage
+	d.Messages.Log("%s attacks your %s for %d damage!", capitalize(e.Title()), ally.Name, damage)
+	if ally.Health <= 0 {
+		d.Messages.Log("Your %s dies!", ally.Name)
+		d.RemoveEnemy(ally)
+	}
+}
+
+// Allies returns the player's living companions on the level
+func (d *Dungeon) Allies() []*Enemy {
+	var allies []*Enemy
+	for _, enemy := range d.Enemies {
+		if enemy.Ally && enemy.Health > 0 {
+			allies = append(allies, enemy)
+		}
+	}
+	return allies
+}
+
+// allyFollowRange is how close a companion must be to follow the player to a new level
+const allyFollowRange = 3
+
+// bringAllies moves the companions that were close to (fromX, fromY), where
+// the player left the old level, onto free tiles next to the player on the new one
+func bringAllies(p *Player, fromX, fromY int, from, to *Dungeon) {
+	for _, ally := range from.Allies() {
+		if distance(ally.X, ally.Y, fromX, fromY) > allyFollowRange {
+			continue
+		}
+		for _, dir := range allDirections {
+			dx, dy := dir.Delta()
+			x, y := p.X+dx, p.Y+dy
+			if to.IsWalkable(x, y) && to.GetEnemyAt(x, y) == nil {
+				from.RemoveEnemy(ally)
+				ally.X, ally.Y = x, y
+				to.AddEnemy(ally)
+				p.Messages.Log("Your %s follows you.", ally.Name)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCompanionKillKeepsOtherTurns(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.clearEnemies()
+	p := newPlayerIn(d, ClassWarrior)
+
+	dog := strayDog.spawn(1, 1)
+	dog.Hostile, dog.Ally = false, true
+	d.AddEnemy(dog)
+	turns := make(map[*Enemy]int)
+	prey := &Enemy{Name: "Rat", X: 2, Y: 1, Health: 1, MaxHealth: 1, Hostile: true, Behavior: countingBehavior{turns, false}}
+	d.AddEnemy(prey)
+	var others []*Enemy
+	for i := 0; i < 2; i++ {
+		enemy := &Enemy{Name: "Goblin", X: 10 + i, Y: 10, Health: 5, Hostile: true, Behavior: countingBehavior{turns, false}}
+		d.AddEnemy(enemy)
+		others = append(others, enemy)
+	}
+
+	d.MoveEnemies(p)
+	if d.present(prey) {
+		t.Fatal("the dog didn't kill the rat")
+	}
+	for _, enemy := range others {
+		if turns[enemy] != 1 {
+			t.Errorf("goblin at (%d,%d) took %d turns, want 1", enemy.X, enemy.Y, turns[enemy])
+		}
+	}
+}
+
+func TestAllyDeathKeepsOtherAttacks(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.clearEnemies()
+	p := newPlayerIn(d, ClassWarrior)
+	p.X, p.Y = 20, 20
+
+	dog := strayDog.spawn(1, 1)
+	dog.Hostile, dog.Ally, dog.Health = false, true, 1
+	d.AddEnemy(dog)
+	biters := []*Enemy{
+		{Name: "Orc", X: 2, Y: 1, Health: 5, Damage: 3, Hostile: true},
+		{Name: "Orc", X: 21, Y: 20, Health: 5, Damage: 3, Hostile: true},
+	}
+	for _, enemy := range biters {
+		d.AddEnemy(enemy)
+	}
+
+	health := p.Health
+	d.EnemiesAttack(p)
+	if d.present(dog) {
+		t.Fatal("the dog survived")
+	}
+	if p.Health == health {
+		t.Error("the orc next to the player lost its attack when the dog died")
+	}
+}
+
+func TestThrowsAndZapsPassOverAllies(t *testing.T) {
+	attacks := map[string]func(p *Player, d *Dungeon){
+		"fire potion": func(p *Player, d *Dungeon) {
+			p.Inventory = append(p.Inventory, NewFirePotion(0, 0))
+			p.ThrowItem(len(p.Inventory)-1, East, d)
+		},
+		"wand of fire": func(p *Player, d *Dungeon) {
+			p.Inventory = append(p.Inventory, NewWand(0, 0, WandFire, 1))
+			p.Zap(len(p.Inventory)-1, East, d)
+		},
+		"wand of lightning": func(p *Player, d *Dungeon) {
+			p.Inventory = append(p.Inventory, NewWand(0, 0, WandLightning, 1))
+			p.Zap(len(p.Inventory)-1, East, d)
+		},
+	}
+	for name, attack := range attacks {
+		t.Run(name, func(t *testing.T) {
+			d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+			d.clearEnemies()
+			p := newPlayerIn(d, ClassWarrior)
+
+			dog := strayDog.spawn(p.X+1, p.Y)
+			dog.Hostile, dog.Ally = false, true
+			dog.Health = 1
+			d.AddEnemy(dog)
+			troll := enemyTypes["Troll"].spawn(p.X+2, p.Y)
+			troll.Health, troll.MaxHealth = 100, 100
+			d.AddEnemy(troll)
+			exp := p.Exp
+
+			attack(p, d)
+			if dog.Health != 1 || !d.present(dog) {
+				t.Errorf("the dog was hit, left with %d health", dog.Health)
+			}
+			if troll.Health == 100 {
+				t.Error("the troll behind the dog wasn't hit")
+			}
+			if p.Stats.TotalKills() != 0 || p.Exp != exp {
+				t.Errorf("the attack counted %d kills and %d experience", p.Stats.TotalKills(), p.Exp-exp)
+			}
+		})
+	}
+}
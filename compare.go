@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+// shownValue is the stat an item appears to have. A cursed item keeps
+// looking as good as its label until it has been equipped.
+func shownValue(item Item) int {
+	if item.Cursed && item.Description != cursedDescription {
+		return item.Listed
+	}
+	return item.Value
+}
+
+// Compare describes how a weapon or armor measures up against what the player
+// has equipped in the same slot, e.g. "Longsword: Attack 6 (+3 vs equipped
+// Short Sword)". It returns "" for anything else.
+func (p *Player) Compare(item Item) string {
+	switch item.Type {
+	case ItemWeapon:
+		current, against := 0, "bare hands"
+		if p.Weapon != nil {
+			current, against = p.Weapon.Value, "equipped "+p.Weapon.Name
+		}
+		value := shownValue(item)
+		return fmt.Sprintf("%s: Attack %d (%+d vs %s)", item.Name, value, value-current, against)
+
+	case ItemArmor:
+		current, against := 0, "no "+item.Slot.Name()+" armor"
+		if worn, ok := p.Armor[item.Slot]; ok {
+			current, against = worn.Value, "equipped "+worn.Name
+		}
+		value := shownValue(item)
+		return fmt.Sprintf("%s: Defense %d (%+d vs %s)", item.Name, value, value-current, against)
+	}
+	return ""
+}
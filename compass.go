@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// compassPoints names the eight directions, clockwise from north
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// bearing returns the compass direction from (x1, y1) toward (x2, y2), with
+// north at the top of the map, or "here" if the points are the same
+func bearing(x1, y1, x2, y2 int) string {
+	if x1 == x2 && y1 == y2 {
+		return "here"
+	}
+
+	// Measure the angle clockwise from north; the map's y axis points down
+	angle := math.Atan2(float64(x2-x1), float64(y1-y2)) * 180 / math.Pi
+	if angle < 0 {
+		angle += 360
+	}
+	return Direction(int(math.Round(angle/45)) % len(compassPoints)).String()
+}
+
+// StairsPos returns the position of the stairs down, if the level has any
+func (d *Dungeon) StairsPos() (int, int, bool) {
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			if d.at(x, y) == rune(StairsDown) {
+				return x, y, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// LocationLine gives the level, the player's coordinates, and the direction
+// of the stairs, e.g. "Depth 2 (Sewers) | Pos: (12,5) | Stairs: NE"
+func (d *Dungeon) LocationLine(p *Player) string {
+	line := fmt.Sprintf("Depth %d (%s) | Pos: (%d,%d)", d.Level, d.Theme.Name, p.X, p.Y)
+	if x, y, ok := d.StairsPos(); ok {
+		line += " | Stairs: " + bearing(p.X, p.Y, x, y)
+	}
+	return line
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Config holds the numbers that tune the game's balance. Every field can be
+// overridden from config.json; anything left out keeps its default.
+type Config struct {
+	// Map generation
+	MapWidth   int `json:"map_width"`   // Width of each dungeon level
+	MapHeight  int `json:"map_height"`  // Height of each dungeon level
+	MinRooms   int `json:"min_rooms"`   // Fewest rooms tried per level
+	MaxRooms   int `json:"max_rooms"`   // Most rooms tried per level
+	MinEnemies int `json:"min_enemies"` // Fewest enemies spawned per level
+	MaxEnemies int `json:"max_enemies"` // Most enemies spawned per level
+
+	// Starting character
+	StartHealth  int    `json:"start_health"`  // Starting and maximum health
+	StartAttack  int    `json:"start_attack"`  // Starting attack damage
+	StartDefense int    `json:"start_defense"` // Starting damage reduction
+	StartMana    int    `json:"start_mana"`    // Starting and maximum mana
+	ExpPerLevel  int    `json:"exp_per_level"` // Experience needed per character level
+	ExpCurve     string `json:"exp_curve"`     // How the experience needed grows: "linear", "quadratic", or "table"
+	ExpTable     []int  `json:"exp_table"`     // Experience needed at each level for the "table" curve; the last entry repeats
+	MaxLevelUps  int    `json:"max_level_ups"` // Most levels gained from a single award of experience; 0 for no limit
+	ExploreExp   int    `json:"explore_exp"`   // Experience for entering a room for the first time
+	DepthExp     int    `json:"depth_exp"`     // Experience for reaching a new deepest level
+
+	// Hazards and recovery
+	TrapDamageMin     int `json:"trap_damage_min"`     // Least damage a trap deals
+	TrapDamageMax     int `json:"trap_damage_max"`     // Most damage a trap deals
+	PitTrapChance     int `json:"pit_trap_chance"`     // Percentage of traps that are pits to the next level
+	AlarmTrapChance   int `json:"alarm_trap_chance"`   // Percentage of traps that raise an alarm
+	RestHealMin       int `json:"rest_heal_min"`       // Least health recovered by resting
+	RestHealMax       int `json:"rest_heal_max"`       // Most health recovered by resting
+	RestInterruptOdds int `json:"rest_interrupt_odds"` // A rest is interrupted one time in this many
+
+	// Goals and safety checks
+	WinLevel         int `json:"win_level"`          // Dungeon level holding the Amulet of Yendor
+	DescendWarnRange int `json:"descend_warn_range"` // Descending with an enemy this close asks for confirmation; 0 or less disables it
+
+	// Preferences, also changed from the settings screen
+	Color      bool   `json:"color"`       // Whether the map and status are drawn in color
+	AutoPickup bool   `json:"auto_pickup"` // Whether new characters pick things up just by stepping on them
+	Difficulty string `json:"difficulty"`  // "easy", "normal", or "hard": how many enemies there are and how much health you start with
+}
+
+// configFile is where the game balance and settings are kept
+const configFile = "config.json"
+
+// DefaultConfig returns the standard game balance
+func DefaultConfig() *Config {
+	return &Config{
+		MapWidth:   80,
+		MapHeight:  24,
+		MinRooms:   4,
+		MaxRooms:   8,
+		MinEnemies: 3,
+		MaxEnemies: 6,
+		
+		StartHealth:  20,
+		StartAttack:  3,
+		StartDefense: 1,
+		StartMana:    10,
+		ExpPerLevel:  100,
+		ExpCurve:     "linear",
+		MaxLevelUps:  1,
+		ExploreExp:   5,
+		DepthExp:     25,
+		
+		TrapDamageMin:     2,
+		TrapDamageMax:     4,
+		PitTrapChance:     15,
+		AlarmTrapChance:   15,
+		RestHealMin:       2,
+		RestHealMax:       4,
+		RestInterruptOdds: 3,
+		
+		WinLevel:         5,
+		DescendWarnRange: 4,
+		
+		Color:      true,
+		AutoPickup: true,
+		Difficulty: "normal",
+	}
+}
+
+// SaveConfig writes the settings to a JSON file, so LoadConfig picks them up next time
+func SaveConfig(path string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Difficulty adjustments to the number of enemies per level and starting health
+const (
+	easyHealth = 10 // Extra starting health on easy
+	hardHealth = -5 // Starting health lost on hard
+)
+
+// enemyRange returns the fewest and most enemies per level at the chosen difficulty
+func (c *Config) enemyRange() (int, int) {
+	switch c.Difficulty {
+	case "easy":
+		return max(1, c.MinEnemies-1), max(1, c.MaxEnemies-1)
+	case "hard":
+		return c.MinEnemies + 1, c.MaxEnemies + 2
+	}
+	return c.MinEnemies, c.MaxEnemies
+}
+
+// startHealth returns the health a new character starts with at the chosen difficulty
+func (c *Config) startHealth() int {
+	switch c.Difficulty {
+	case "easy":
+		return c.StartHealth + easyHealth
+	case "hard":
+		return max(1, c.StartHealth+hardHealth)
+	}
+	return c.StartHealth
+}
+
+// LoadConfig reads game settings from a JSON file on top of the defaults.
+// A missing file simply gives the defaults; settings that would break the
+// game are an error.
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// minMapSize is the smallest width or height a level can be generated at:
+// the largest room plus the wall around it
+const minMapSize = 13
+
+// Validate checks that every setting is one the game can play with,
+// describing the first that isn't
+func (c *Config) Validate() error {
+	checks := []struct {
+		ok      bool
+		problem string
+	}{
+		{c.MapWidth >= minMapSize, fmt.Sprintf("map_width must be at least %d", minMapSize)},
+		{c.MapHeight >= minMapSize, fmt.Sprintf("map_height must be at least %d", minMapSize)},
+		{c.MinRooms >= 0, "min_rooms can't be negative"},
+		{c.MaxRooms >= c.MinRooms, "max_rooms can't be less than min_rooms"},
+		{c.MinEnemies >= 0, "min_enemies can't be negative"},
+		{c.MaxEnemies >= c.MinEnemies, "max_enemies can't be less than min_enemies"},
+		
+		{c.StartHealth >= 1, "start_health must be at least 1"},
+		{c.StartAttack >= 0, "start_attack can't be negative"},
+		{c.StartDefense >= 0, "start_defense can't be negative"},
+		{c.StartMana >= 0, "start_mana can't be negative"},
+		{c.ExpPerLevel >= 1, "exp_per_level must be at least 1"},
+		{c.ExpCurve == "linear" || c.ExpCurve == "quadratic" || c.ExpCurve == "table", `exp_curve must be "linear", "quadratic", or "table"`},
+		{c.ExpCurve != "table" || len(c.ExpTable) > 0, `exp_table needs at least one entry for the "table" curve`},
+		{allPositive(c.ExpTable), "every exp_table entry must be at least 1"},
+		{c.MaxLevelUps >= 0, "max_level_ups can't be negative"},
+		{c.ExploreExp >= 0, "explore_exp can't be negative"},
+		{c.DepthExp >= 0, "depth_exp can't be negative"},
+		
+		{c.TrapDamageMin >= 1, "trap_damage_min must be at least 1"},
+		{c.TrapDamageMax >= c.TrapDamageMin, "trap_damage_max can't be less than trap_damage_min"},
+		{c.PitTrapChance >= 0 && c.AlarmTrapChance >= 0, "pit_trap_chance and alarm_trap_chance can't be negative"},
+		{c.PitTrapChance+c.AlarmTrapChance <= 100, "pit_trap_chance and alarm_trap_chance can't add up to more than 100"},
+		{c.RestHealMin >= 0, "rest_heal_min can't be negative"},
+		{c.RestHealMax >= c.RestHealMin, "rest_heal_max can't be less than rest_heal_min"},
+		{c.RestInterruptOdds >= 1, "rest_interrupt_odds must be at least 1"},
+		
+		{c.WinLevel >= 1, "win_level must be at least 1"},
+		{validDifficulty(c.Difficulty), `difficulty must be "easy", "normal", or "hard"`},
+	}
+	for _, check := range checks {
+		if !check.ok {
+			return errors.New(check.problem)
+		}
+	}
+	return nil
+}
+
+// allPositive reports whether every number in the list is at least 1
+func allPositive(numbers []int) bool {
+	for _, n := range numbers {
+		if n < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// validDifficulty reports whether the difficulty is one the settings screen offers
+func validDifficulty(difficulty string) bool {
+	for _, name := range difficulties {
+		if name == difficulty {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigRejectsUnplayableSettings(t *testing.T) {
+	tests := []struct {
+		json  string
+		field string
+	}{
+		{`{"rest_interrupt_odds": 0}`, "rest_interrupt_odds"},
+		{`{"rest_heal_min": 5, "rest_heal_max": 2}`, "rest_heal_max"},
+		{`{"trap_damage_min": 0}`, "trap_damage_min"},
+		{`{"trap_damage_min": 6, "trap_damage_max": 3}`, "trap_damage_max"},
+		{`{"min_rooms": 5, "max_rooms": 2}`, "max_rooms"},
+		{`{"min_enemies": 4, "max_enemies": 1}`, "max_enemies"},
+		{`{"map_width": 10}`, "map_width"},
+		{`{"start_health": 0}`, "start_health"},
+		{`{"exp_per_level": 0}`, "exp_per_level"},
+		{`{"exp_curve": "cubic"}`, "exp_curve"},
+		{`{"exp_curve": "table"}`, "exp_table"},
+		{`{"exp_table": [100, 0]}`, "exp_table"},
+		{`{"pit_trap_chance": 70, "alarm_trap_chance": 40}`, "pit_trap_chance"},
+		{`{"difficulty": "nightmare"}`, "difficulty"},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(path, []byte(tt.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadConfig(path)
+		if err == nil {
+			t.Errorf("%s loaded without complaint", tt.json)
+		} else if !strings.Contains(err.Error(), tt.field) {
+			t.Errorf("%s: error %q doesn't name %s", tt.json, err, tt.field)
+		}
+		if cfg != nil {
+			t.Errorf("%s: got a config along with the error", tt.json)
+		}
+	}
+}
+
+func TestLoadConfigAcceptsDefaultsAndTweaks(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("the default config is invalid: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("a missing config file gave %v, want the defaults", err)
+	}
+	tweaks := `{"rest_heal_min": 3, "rest_heal_max": 3, "exp_curve": "table", "exp_table": [50, 80], "descend_warn_range": -4}`
+	if err := os.WriteFile(path, []byte(tweaks), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("loading %s: %v", tweaks, err)
+	}
+	if cfg.RestHealMax != 3 || cfg.ExpTable[1] != 80 {
+		t.Errorf("the tweaks weren't loaded: %+v", cfg)
+	}
+}
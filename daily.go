@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"os"
+	"sort"
+	"time"
+)
+
+// dailyBoardFile is where daily challenge results are kept
+const dailyBoardFile = "daily.json"
+
+// DailyEntry is one finished daily challenge run
+type DailyEntry struct {
+	Name  string `json:"name,omitempty"` // Missing from runs saved before players had names
+	Score int    `json:"score"`
+	Level int    `json:"level"` // Deepest dungeon level reached
+	Turns int    `json:"turns"`
+	Won   bool   `json:"won"`
+}
+
+// Player returns who played the run
+func (e DailyEntry) Player() string {
+	if e.Name == "" {
+		return defaultName
+	}
+	return e.Name
+}
+
+// today returns the current UTC date, which names the daily challenge
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+// seedFromString hashes text into a seed with FNV-1a, so the same text always
+// gives the same dungeon
+func seedFromString(text string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	return int64(h.Sum64())
+}
+
+// dailySeed returns the seed everyone shares for the given date's challenge
+func dailySeed(date string) int64 {
+	return seedFromString("daily-" + date)
+}
+
+// loadDailyBoard reads every recorded daily run, keyed by date
+func loadDailyBoard() (map[string][]DailyEntry, error) {
+	board := map[string][]DailyEntry{}
+	data, err := os.ReadFile(dailyBoardFile)
+	if os.IsNotExist(err) {
+		return board, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &board); err != nil {
+		return nil, err
+	}
+	return board, nil
+}
+
+// recordDailyRun adds a run to the date's leaderboard and returns the
+// updated leaderboard, best score first
+func recordDailyRun(date string, entry DailyEntry) ([]DailyEntry, error) {
+	board, err := loadDailyBoard()
+	if err != nil {
+		return nil, err
+	}
+	
+	runs := append(board[date], entry)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Score > runs[j].Score })
+	board[date] = runs
+	
+	data, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return runs, os.WriteFile(dailyBoardFile, data, 0644)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// DamageType is the element an attack deals its damage in
+type DamageType int
+
+const (
+	Physical DamageType = iota
+	Fire
+	Ice
+	Poison
+	Lightning
+)
+
+// Name returns a lowercase name for the damage type
+func (t DamageType) Name() string {
+	switch t {
+	case Fire:
+		return "fire"
+	case Ice:
+		return "ice"
+	case Poison:
+		return "poison"
+	case Lightning:
+		return "lightning"
+	default:
+		return "physical"
+	}
+}
+
+const (
+	freezeChance   = 25 // Percent chance an ice attack freezes what it hits
+	freezeDuration = 2  // Turns a frozen enemy stays frozen
+)
+
+// rollDamage varies an attack's base damage by up to about 25% either way,
+// keeping the average at the base value. Attacks always deal at least 1.
+func rollDamage(rng *rand.Rand, base int) int {
+	spread := (base + 2) / 4
+	damage := base + rng.Intn(2*spread+1) - spread
+	if damage < 1 {
+		damage = 1
+	}
+	return damage
+}
+
+// resist scales damage by a resistance percentage: 50 halves it, 100 negates
+// it entirely, and a negative value is a weakness that increases it
+func resist(amount int, t DamageType, resistances map[DamageType]int) int {
+	return amount * (100 - resistances[t]) / 100
+}
+
+// describeResistances summarizes resistances and weaknesses for the examine output,
+// or returns "" if there are none
+func describeResistances(resistances map[DamageType]int) string {
+	var resists, weak []string
+	for t := Physical; t <= Poison; t++ {
+		switch r := resistances[t]; {
+		case r >= 100:
+			resists = append(resists, t.Name()+" (immune)")
+		case r > 0:
+			resists = append(resists, t.Name())
+		case r < 0:
+			weak = append(weak, t.Name())
+		}
+	}
+	
+	parts := []string{}
+	if len(resists) > 0 {
+		parts = append(parts, "Resists "+strings.Join(resists, ", ")+".")
+	}
+	if len(weak) > 0 {
+		parts = append(parts, "Weak to "+strings.Join(weak, ", ")+".")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,61 @@
+package main
+
+// Direction is one of the eight compass directions, clockwise from north,
+// with north at the top of the map
+type Direction int
+
+const (
+	North Direction = iota
+	NorthEast
+	East
+	SouthEast
+	South
+	SouthWest
+	West
+	NorthWest
+)
+
+// directionDeltas gives the offset of one step in each direction
+var directionDeltas = [...]struct{ dx, dy int }{
+	North:     {0, -1},
+	NorthEast: {1, -1},
+	East:      {1, 0},
+	SouthEast: {1, 1},
+	South:     {0, 1},
+	SouthWest: {-1, 1},
+	West:      {-1, 0},
+	NorthWest: {-1, -1},
+}
+
+// orthogonalDirections are the four directions the player and most enemies move in
+var orthogonalDirections = []Direction{North, East, South, West}
+
+// allDirections includes the diagonals as well
+var allDirections = []Direction{North, NorthEast, East, SouthEast, South, SouthWest, West, NorthWest}
+
+// Delta returns the offset of one step in the direction
+func (dir Direction) Delta() (dx, dy int) {
+	delta := directionDeltas[dir]
+	return delta.dx, delta.dy
+}
+
+// Opposite returns the direction pointing the other way
+func (dir Direction) Opposite() Direction {
+	return (dir + 4) % 8
+}
+
+// String returns the compass abbreviation, e.g. "NE"
+func (dir Direction) String() string {
+	return compassPoints[dir]
+}
+
+// facing returns the direction of a single step by (dx, dy), each -1, 0, or
+// 1, or false if the step goes nowhere
+func facing(dx, dy int) (Direction, bool) {
+	for _, dir := range allDirections {
+		if delta := directionDeltas[dir]; delta.dx == dx && delta.dy == dy {
+			return dir, true
+		}
+	}
+	return North, false
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"math/rand"
-	"time"
+	"os"
+	"strings"
 )
 
 // TileType represents different types of dungeon tiles
@@ -16,8 +18,34 @@ const (
 	Treasure  TileType = '$'  // Treasure (can be collected)
 	Trap      TileType = '^'  // Trap (causes damage)
 	StairsDown TileType = '>' // Stairs to next level
+	Teleporter TileType = '0' // Sends whoever steps on it to its partner
+	Chest     TileType = 'C'  // Locked chest (needs a key)
 )
 
+// Name returns a human-readable name for the tile type
+func (t TileType) Name() string {
+	switch t {
+	case Floor:
+		return "Floor"
+	case Wall:
+		return "Wall"
+	case Door:
+		return "Door"
+	case Treasure:
+		return "Treasure"
+	case Trap:
+		return "Trap"
+	case StairsDown:
+		return "Stairs down"
+	case Teleporter:
+		return "Teleporter"
+	case Chest:
+		return "Locked chest"
+	default:
+		return "Unknown"
+	}
+}
+
 // Room represents a rectangular room in the dungeon
 type Room struct {
 	X, Y          int // Top-left corner
@@ -28,56 +56,188 @@ type Room struct {
 type Enemy struct {
 	X, Y    int
 	Health  int
+	MaxHealth int
 	Symbol  rune
 	Name    string
 	Damage  int
 	Hostile bool
+	Behavior EnemyBehavior // AI that drives the enemy's turns
+	RegenPerTurn int       // Health regained every turn, up to MaxHealth
+	AttackType  DamageType // Element of the enemy's attacks
+	Resistances map[DamageType]int // Percentage of each damage type ignored; negative for weaknesses
+	Elite       bool       // Whether the enemy rolled any modifiers
+	Fast        bool       // Fast enemies act twice per turn
+	Venomous    bool       // Venomous enemies poison the player on hit
+	SlowTurns   int        // Turns left of being slowed, acting only every other turn
+	FrozenTurns int        // Turns left frozen solid, unable to act at all
+	StolenGold  int        // Gold a thief has taken from the player
+	Disguised   bool       // Disguised mimics look like treasure until found out
+	Boss        bool       // Bosses are named and guard the amulet
+	Alerted     bool       // Alerted enemies hunt the player from any distance
+	Aware       bool       // Whether the enemy has noticed the player; unaware ones take sneak attacks
+	Ally        bool       // Allies follow the player and fight at their side
+	Spotted     bool       // Whether the enemy could see the player on its last turn
+	Cooldown    int        // Turns before the enemy can use its special ability again
+	Reassembled int        // Times a skeleton has pulled itself back together
+	Flying      bool       // Flying enemies pass over traps without setting them off
+}
+
+// enemyType describes the base stats of a kind of enemy
+type enemyType struct {
+	name     string
+	symbol   rune
+	health   int
+	damage   int
+	behavior EnemyBehavior
+	regen    int
+	attack   DamageType
+	resist   map[DamageType]int
+	flying   bool
+}
+
+// spawn creates a fresh, hostile enemy of this type at (x, y)
+func (t enemyType) spawn(x, y int) *Enemy {
+	return &Enemy{
+		X:       x,
+		Y:       y,
+		Health:  t.health,
+		MaxHealth: t.health,
+		Symbol:  t.symbol,
+		Name:    t.name,
+		Damage:  t.damage,
+		Hostile: true,
+		Behavior: t.behavior,
+		RegenPerTurn: t.regen,
+		AttackType:  t.attack,
+		Resistances: t.resist,
+		Flying:      t.flying,
+	}
+}
+
+// enemyTypes holds every enemy type, keyed by name
+var enemyTypes = map[string]enemyType{
+	"Goblin":   {"Goblin", 'g', 3, 1, CowardBehavior{FleePercent: 50}, 0, Physical, nil, false},
+	"Orc":      {"Orc", 'o', 5, 2, RallyBehavior{Chance: 35, Cooldown: 30}, 0, Physical, nil, false},
+	"Troll":    {"Troll", 'T', 8, 3, MeleeBehavior{}, 1, Physical, map[DamageType]int{Fire: -50}, false},
+	"Rat":      {"Rat", 'r', 1, 1, MeleeBehavior{}, 0, Physical, nil, false},
+	"Skeleton": {"Skeleton", 's', 4, 2, BoneBehavior{RangedBehavior{Range: 6}, 40}, 0, Physical, map[DamageType]int{Fire: -50, Poison: 100}, false},
+	"Mold":     {"Mold", 'm', 4, 1, StationaryBehavior{}, 0, Poison, map[DamageType]int{Poison: 100, Fire: -50}, false},
+	"Thief":    {"Thief", 't', 4, 1, ThiefBehavior{}, 0, Physical, nil, false},
+	"Mimic":    {"Mimic", 'M', 6, 3, MimicBehavior{}, 0, Physical, nil, false},
+	"Ghost":    {"Ghost", 'G', 3, 2, GhostBehavior{}, 0, Physical, map[DamageType]int{Physical: 50, Poison: 100, Fire: -50}, false},
+	"Bat":      {"Bat", 'b', 2, 1, BatBehavior{}, 0, Physical, nil, true},
 }
 
-// Dungeon represents the game map as a 2D grid of runes (characters)
+// Dungeon represents the game map as a grid of runes (characters)
 type Dungeon struct {
 	Width, Height int       // Dimensions of the dungeon
-	Grid          [][]rune  // 2D grid representing the dungeon layout
+	Grid          []rune    // Dungeon layout, stored row by row
 	Rooms         []Room    // List of rooms in the dungeon
 	Enemies       []*Enemy  // List of enemies in the dungeon
 	Items         []Item    // List of items in the dungeon
 	Level         int       // Current dungeon level
+	Theme         Theme     // Look and inhabitants of this level
+	Explored      [][]bool  // Tiles the player has seen at least once
+	Visited       map[*Room]bool        // Rooms the player has stepped into
+	Teleporters   map[[2]int][2]int     // Each teleporter and the partner it leads to
+	Chests        map[[2]int]Item       // What each locked chest holds
+	Bones         []Bones               // Skeleton remains waiting to reassemble
+	Fires         map[[2]int]int        // Turns each tile has left to burn; burnt-out tiles stay at 0 and can't catch again
+	Traps         map[[2]int]*TrapState // Every trap on the level, hidden or not
+	SecretDoors   map[[2]int]bool       // Walls that hide a door to a bonus room
+	Rng           *rand.Rand            // Source of randomness for the level and its inhabitants
+	Messages      *MessageLog           // Where what happens on the level is logged
+	Config        *Config               // Game balance settings
+	Revealed      bool                  // Debug view: everything is visible regardless of light
+	Start         [2]int                // Where the player begins on a level loaded from a file
+	enemyAt       map[[2]int]*Enemy     // Enemies indexed by position
+	itemAt        map[[2]int]int        // Index into Items of the item shown at each position
 }
 
-// NewDungeon creates a new dungeon of width w and height h
-func NewDungeon(w, h int) *Dungeon {
-	// Seed the random number generator
-	rand.Seed(time.Now().UnixNano())
+// TrapState tracks a trap stored apart from the grid
+type TrapState struct {
+	Hidden bool     // Hidden traps are drawn as floor until revealed
+	Kind   TrapKind // What the trap does when set off
+	Under  rune     // Terrain the revealed trap is drawn over, put back when it's gone
+}
+
+// TrapKind is what a trap does to whoever sets it off
+type TrapKind int
+
+const (
+	TrapSpikes TrapKind = iota // Deals damage
+	TrapPit                    // Drops the player to the next level
+	TrapAlarm                  // Summons and alerts nearby enemies
+)
+
+// Name returns how a spotted trap of this kind is described
+func (k TrapKind) Name() string {
+	switch k {
+	case TrapPit:
+		return "Pit trap"
+	case TrapAlarm:
+		return "Alarm trap"
+	default:
+		return Trap.Name()
+	}
+}
+
+// NewDungeon creates a new dungeon for the given level, sized and populated
+// according to cfg and drawing every random choice from rng
+func NewDungeon(level int, rng *rand.Rand, cfg *Config) *Dungeon {
+	d := newSolidDungeon(cfg.MapWidth, cfg.MapHeight, level, rng, cfg)
 	
+	// Generate rooms and corridors
+	d.generateRooms(cfg.MinRooms, cfg.MaxRooms)
+	d.connectRooms()              // Connect rooms with corridors
+	d.addSecretRoom()             // Maybe hide a treasure room
+	d.addFeatures(d.Theme)        // Add doors, traps, treasures
+	minEnemies, maxEnemies := cfg.enemyRange()
+	d.spawnEnemies(minEnemies, maxEnemies, d.Theme)
+	d.addStray()                  // Maybe add a dog to befriend
+	
+	return d
+}
+
+// newSolidDungeon creates a w by h level of solid wall, with nothing explored
+// yet, ready for rooms to be carved out of it
+func newSolidDungeon(w, h, level int, rng *rand.Rand, cfg *Config) *Dungeon {
 	// Create a new dungeon instance
 	d := &Dungeon{
 		Width:  w,
 		Height: h,
-		Level:  1,
+		Level:       level,
+		Rng:         rng,
+		Config:      cfg,
+		Theme:       themeForLevel(level),
+		Traps:       make(map[[2]int]*TrapState),
+		SecretDoors: make(map[[2]int]bool),
+		Visited:     make(map[*Room]bool),
+		Teleporters: make(map[[2]int][2]int),
+		Chests:      make(map[[2]int]Item),
+		Fires:       make(map[[2]int]int),
+		enemyAt:     make(map[[2]int]*Enemy),
+		itemAt:      make(map[[2]int]int),
 	}
 	
 	// Initialize the grid with walls
-	d.Grid = make([][]rune, h)
-	for y := range d.Grid {
-		d.Grid[y] = make([]rune, w)
-		for x := range d.Grid[y] {
-			d.Grid[y][x] = rune(Wall) // Initialize all cells as walls
-		}
+	d.Grid = make([]rune, w*h)
+	for i := range d.Grid {
+		d.Grid[i] = rune(Wall) // Initialize all cells as walls
 	}
 	
-	// Generate rooms and corridors
-	d.generateRooms(4, 8) // Generate between 4-8 rooms
-	d.connectRooms()      // Connect rooms with corridors
-	d.addFeatures()       // Add doors, traps, treasures
-	d.spawnEnemies(3, 6)  // Spawn 3-6 enemies
-	
+	// Nothing has been explored yet
+	d.Explored = make([][]bool, h)
+	for y := range d.Explored {
+		d.Explored[y] = make([]bool, w)
+	}
 	return d
 }
 
 // generateRooms creates random rooms in the dungeon
 func (d *Dungeon) generateRooms(minRooms, maxRooms int) {
 	// Determine number of rooms to generate
-	numRooms := minRooms + rand.Intn(maxRooms-minRooms+1)
+	numRooms := minRooms + d.Rng.Intn(maxRooms-minRooms+1)
 	
 	// Room size constraints
 	minSize := 4
@@ -86,27 +246,18 @@ func (d *Dungeon) generateRooms(minRooms, maxRooms int) {
 	// Try to place rooms
 	for i := 0; i < numRooms; i++ {
 		// Random room dimensions
-		width := minSize + rand.Intn(maxSize-minSize+1)
-		height := minSize + rand.Intn(maxSize-minSize+1)
+		width := minSize + d.Rng.Intn(maxSize-minSize+1)
+		height := minSize + d.Rng.Intn(maxSize-minSize+1)
 		
 		// Random position (leaving border)
-		x := 1 + rand.Intn(d.Width-width-2)
-		y := 1 + rand.Intn(d.Height-height-2)
+		x := 1 + d.Rng.Intn(d.Width-width-2)
+		y := 1 + d.Rng.Intn(d.Height-height-2)
 		
 		// Create new room
 		newRoom := Room{X: x, Y: y, Width: width, Height: height}
 		
-		// Check if room overlaps with existing rooms
-		overlap := false
-		for _, room := range d.Rooms {
-			if roomsOverlap(newRoom, room) {
-				overlap = true
-				break
-			}
-		}
-		
 		// If no overlap, add the room
-		if !overlap {
+		if !d.overlapsRoom(newRoom) {
 			d.carveRoom(newRoom)
 			d.Rooms = append(d.Rooms, newRoom)
 		}
@@ -120,6 +271,17 @@ func (d *Dungeon) generateRooms(minRooms, maxRooms int) {
 	}
 }
 
+// overlapsRoom reports whether a room would overlap any existing room,
+// stopping at the first one it hits
+func (d *Dungeon) overlapsRoom(newRoom Room) bool {
+	for _, room := range d.Rooms {
+		if roomsOverlap(newRoom, room) {
+			return true
+		}
+	}
+	return false
+}
+
 // roomsOverlap checks if two rooms overlap (including a 1-tile buffer)
 func roomsOverlap(r1, r2 Room) bool {
 	return r1.X-1 <= r2.X+r2.Width && r1.X+r1.Width+1 >= r2.X &&
@@ -130,7 +292,7 @@ func roomsOverlap(r1, r2 Room) bool {
 func (d *Dungeon) carveRoom(room Room) {
 	for y := room.Y; y < room.Y+room.Height; y++ {
 		for x := room.X; x < room.X+room.Width; x++ {
-			d.Grid[y][x] = rune(Floor)
+			d.set(x, y, rune(Floor))
 		}
 	}
 }
@@ -151,7 +313,7 @@ func (d *Dungeon) connectRooms() {
 		y2 := d.Rooms[i+1].Y + d.Rooms[i+1].Height/2
 		
 		// Randomly decide whether to go horizontal first or vertical first
-		if rand.Intn(2) == 0 {
+		if d.Rng.Intn(2) == 0 {
 			// Horizontal then vertical
 			d.createHorizontalCorridor(x1, x2, y1)
 			d.createVerticalCorridor(y1, y2, x2)
@@ -173,7 +335,7 @@ func (d *Dungeon) createHorizontalCorridor(x1, x2, y int) {
 	// Create corridor
 	for x := x1; x <= x2; x++ {
 		if y >= 0 && y < d.Height && x >= 0 && x < d.Width {
-			d.Grid[y][x] = rune(Floor)
+			d.set(x, y, rune(Floor))
 		}
 	}
 }
@@ -188,47 +350,200 @@ func (d *Dungeon) createVerticalCorridor(y1, y2, x int) {
 	// Create corridor
 	for y := y1; y <= y2; y++ {
 		if y >= 0 && y < d.Height && x >= 0 && x < d.Width {
-			d.Grid[y][x] = rune(Floor)
+			d.set(x, y, rune(Floor))
 		}
 	}
 }
 
+// addSecretRoom tries to place a bonus treasure room next to an existing room,
+// reachable only through a secret door
+func (d *Dungeon) addSecretRoom() {
+	// 50% chance per level
+	if d.Rng.Intn(100) >= 50 {
+		return
+	}
+	
+	for attempts := 0; attempts < 20; attempts++ {
+		room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+		width := 3 + d.Rng.Intn(2)
+		height := 3 + d.Rng.Intn(2)
+		
+		// Put the bonus room to the right of or below the chosen room,
+		// leaving a single wall tile between them
+		var bonus Room
+		var doorX, doorY int
+		if d.Rng.Intn(2) == 0 {
+			bonus = Room{X: room.X + room.Width + 1, Y: room.Y, Width: width, Height: height}
+			doorX, doorY = room.X+room.Width, room.Y+d.Rng.Intn(min(room.Height, height))
+		} else {
+			bonus = Room{X: room.X, Y: room.Y + room.Height + 1, Width: width, Height: height}
+			doorX, doorY = room.X+d.Rng.Intn(min(room.Width, width)), room.Y+room.Height
+		}
+		
+		// The bonus room and its surrounding walls must be untouched rock
+		if !d.isSolid(bonus.X-1, bonus.Y-1, bonus.Width+2, bonus.Height+2) {
+			continue
+		}
+		
+		d.carveRoom(bonus)
+		d.SecretDoors[[2]int{doorX, doorY}] = true
+		
+		// Fill the room with loot
+		treasureX := bonus.X + bonus.Width/2
+		treasureY := bonus.Y + bonus.Height/2
+		d.set(treasureX, treasureY, rune(Treasure))
+		d.AddItem(Item{
+			X:      treasureX,
+			Y:      treasureY,
+			Type:   ItemTreasure,
+			Name:   "Gold",
+			Value:  50 + d.Rng.Intn(50), // 50-99 gold
+			Symbol: '$',
+		})
+		d.AddItem(NewHealthPotion(bonus.X, bonus.Y))
+		return
+	}
+}
+
+// isSolid checks whether the given rectangle lies inside the map and is entirely wall
+func (d *Dungeon) isSolid(x, y, w, h int) bool {
+	if x < 1 || y < 1 || x+w >= d.Width || y+h >= d.Height {
+		return false
+	}
+	for cy := y; cy < y+h; cy++ {
+		for cx := x; cx < x+w; cx++ {
+			if d.at(cx, cy) != rune(Wall) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// RevealSecretDoor turns a secret door into a real door
+func (d *Dungeon) RevealSecretDoor(x, y int) {
+	if d.SecretDoors[[2]int{x, y}] {
+		delete(d.SecretDoors, [2]int{x, y})
+		d.set(x, y, rune(Door))
+	}
+}
+
 // addFeatures adds doors, traps, and treasures to the dungeon
-func (d *Dungeon) addFeatures() {
+func (d *Dungeon) addFeatures(theme Theme) {
 	// Add doors between corridors and rooms
 	d.addDoors()
 	
 	// Add treasures in rooms
 	d.addTreasures()
 	
-	// Add traps in corridors
-	d.addTraps()
+	// Add potions in rooms
+	d.addPotions()
+	
+	// Add food in rooms
+	d.addFood()
+	
+	// Occasionally add a spellbook
+	d.addSpellbook()
+	
+	// Occasionally add a torch
+	d.addTorch()
+	
+	// Occasionally add a weapon or armor
+	d.addEquipment()
+	
+	// Occasionally add a ring or pendant
+	d.addAccessory()
+	
+	// Rarely add a scroll
+	d.addScroll()
+	
+	// Rarely add a wand
+	d.addWand()
+	
+	// Occasionally add a bomb
+	d.addBomb()
+	
+	// Add stairs to next level, away from where the player starts
+	d.addStairs()
+	
+	// Sometimes link two rooms with teleporters
+	d.addTeleporters()
+	
+	// Occasionally add locked chests and their keys
+	d.addChests()
+	
+	// Add traps in corridors and rooms last, so they only go on plain floor
+	// and never under the stairs or another feature
+	d.addTraps(theme.MinTraps, theme.MaxTraps)
+}
+
+// addTeleporters has a chance to link two different rooms with a pair of teleporters
+func (d *Dungeon) addTeleporters() {
+	// 30% chance per level
+	if len(d.Rooms) < 3 || d.Rng.Intn(100) >= 30 {
+		return
+	}
+	
+	// Pick two different rooms and a free floor tile in each
+	first := d.Rng.Intn(len(d.Rooms))
+	second := (first + 1 + d.Rng.Intn(len(d.Rooms)-1)) % len(d.Rooms)
+	var ends [][2]int
+	for _, i := range []int{first, second} {
+		if x, y, ok := d.emptyTileIn(d.Rooms[i]); ok {
+			ends = append(ends, [2]int{x, y})
+		}
+	}
+	if len(ends) < 2 {
+		return
+	}
 	
-	// Add stairs to next level in the last room
-	if len(d.Rooms) > 0 {
-		lastRoom := d.Rooms[len(d.Rooms)-1]
-		stairsX := lastRoom.X + lastRoom.Width/2
-		stairsY := lastRoom.Y + lastRoom.Height/2
-		d.Grid[stairsY][stairsX] = rune(StairsDown)
+	for i, end := range ends {
+		d.set(end[0], end[1], rune(Teleporter))
+		d.Teleporters[end] = ends[1-i]
 	}
 }
 
-// addDoors adds doors at appropriate locations
+// emptyTileIn picks a random floor tile in room with no item, trap, or enemy
+// on it, returning false if none turned up
+func (d *Dungeon) emptyTileIn(room Room) (int, int, bool) {
+	for attempts := 0; attempts < 20; attempts++ {
+		x := room.X + d.Rng.Intn(room.Width)
+		y := room.Y + d.Rng.Intn(room.Height)
+		if d.at(x, y) == rune(Floor) && d.GetItemAt(x, y) == nil && d.Traps[[2]int{x, y}] == nil && d.GetEnemyAt(x, y) == nil {
+			return x, y, true
+		}
+	}
+	return 0, 0, false
+}
+
+// addDoors adds doors where corridors enter rooms. Only the ring of tiles just
+// outside each room is checked, rather than every cell of the map.
 func (d *Dungeon) addDoors() {
-	// For simplicity, we'll just add some random doors
-	// A more sophisticated algorithm would place doors at corridor-room junctions
-	for y := 1; y < d.Height-1; y++ {
-		for x := 1; x < d.Width-1; x++ {
-			// Check if this is a potential door location (floor with walls on opposite sides)
-			if d.Grid[y][x] == rune(Floor) {
-				if (d.Grid[y-1][x] == rune(Wall) && d.Grid[y+1][x] == rune(Wall)) ||
-					(d.Grid[y][x-1] == rune(Wall) && d.Grid[y][x+1] == rune(Wall)) {
-					// 10% chance to place a door
-					if rand.Intn(100) < 10 {
-						d.Grid[y][x] = rune(Door)
-					}
-				}
-			}
+	for _, room := range d.Rooms {
+		// Walk the top and bottom edges, corners included
+		for x := room.X - 1; x <= room.X+room.Width; x++ {
+			d.maybeAddDoor(x, room.Y-1)
+			d.maybeAddDoor(x, room.Y+room.Height)
+		}
+		// Walk the left and right edges
+		for y := room.Y; y < room.Y+room.Height; y++ {
+			d.maybeAddDoor(room.X-1, y)
+			d.maybeAddDoor(room.X+room.Width, y)
+		}
+	}
+}
+
+// maybeAddDoor places a door at (x, y) with a 10% chance if it is a corridor
+// tile squeezed between two walls
+func (d *Dungeon) maybeAddDoor(x, y int) {
+	if x < 1 || x >= d.Width-1 || y < 1 || y >= d.Height-1 || d.at(x, y) != rune(Floor) {
+		return
+	}
+	if (d.at(x, y-1) == rune(Wall) && d.at(x, y+1) == rune(Wall)) ||
+		(d.at(x-1, y) == rune(Wall) && d.at(x+1, y) == rune(Wall)) {
+		// 10% chance to place a door
+		if d.Rng.Intn(100) < 10 {
+			d.set(x, y, rune(Door))
 		}
 	}
 }
@@ -236,63 +551,281 @@ func (d *Dungeon) addDoors() {
 // addTreasures adds treasure items to rooms
 func (d *Dungeon) addTreasures() {
 	// Add treasures to some rooms
-	for _, room := range d.Rooms {
+	for i, room := range d.Rooms {
 		// 40% chance for a room to have treasure
-		if rand.Intn(100) < 40 {
+		if d.Rng.Intn(100) < 40 {
 			// Place treasure at random position in room
-			treasureX := room.X + rand.Intn(room.Width)
-			treasureY := room.Y + rand.Intn(room.Height)
-			d.Grid[treasureY][treasureX] = rune(Treasure)
+			treasureX := room.X + d.Rng.Intn(room.Width)
+			treasureY := room.Y + d.Rng.Intn(room.Height)
+			d.set(treasureX, treasureY, rune(Treasure))
+			
+			// Away from the starting room, one treasure in ten is a mimic
+			if i > 0 && d.Rng.Intn(10) == 0 {
+				mimic := enemyTypes["Mimic"].spawn(treasureX, treasureY)
+				mimic.Disguised = true
+				d.AddEnemy(mimic)
+				continue
+			}
 			
 			// Add to items list
-			d.Items = append(d.Items, Item{
+			d.AddItem(Item{
 				X:      treasureX,
 				Y:      treasureY,
 				Type:   ItemTreasure,
 				Name:   "Gold",
-				Value:  10 + rand.Intn(90), // 10-99 gold
+				Value:  10 + d.Rng.Intn(90), // 10-99 gold
 				Symbol: '$',
 			})
 		}
 	}
 }
 
+// addPotions scatters a few potions across the rooms
+func (d *Dungeon) addPotions() {
+	for _, room := range d.Rooms {
+		// 30% chance for a room to have a potion
+		if d.Rng.Intn(100) < 30 {
+			x := room.X + d.Rng.Intn(room.Width)
+			y := room.Y + d.Rng.Intn(room.Height)
+			
+			// Don't stack potions on top of other features
+			if d.at(x, y) != rune(Floor) || d.GetItemAt(x, y) != nil {
+				continue
+			}
+			
+			// About one in three potions is a potion of fire, and
+			// one in thirty a rare potion of strength
+			switch roll := d.Rng.Intn(30); {
+			case roll == 0:
+				d.AddItem(NewStrengthPotion(x, y))
+			case roll <= 10:
+				d.AddItem(NewFirePotion(x, y))
+			default:
+				d.AddItem(NewHealthPotion(x, y))
+			}
+		}
+	}
+}
+
+// addFood places food rations in some rooms
+func (d *Dungeon) addFood() {
+	for _, room := range d.Rooms {
+		// 25% chance for a room to have food
+		if d.Rng.Intn(100) < 25 {
+			x := room.X + d.Rng.Intn(room.Width)
+			y := room.Y + d.Rng.Intn(room.Height)
+			if d.at(x, y) == rune(Floor) && d.GetItemAt(x, y) == nil {
+				d.AddItem(NewFood(x, y))
+			}
+		}
+	}
+}
+
+// addSpellbook has a chance to place a spellbook in a random room
+func (d *Dungeon) addSpellbook() {
+	// 25% chance per level
+	if d.Rng.Intn(100) >= 25 {
+		return
+	}
+	
+	room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) != rune(Floor) || d.GetItemAt(x, y) != nil {
+		return
+	}
+	
+	spell := SpellType(d.Rng.Intn(len(spells)))
+	d.AddItem(NewSpellbook(x, y, spell))
+}
+
+// addTorch has a chance to place a torch in a random room
+func (d *Dungeon) addTorch() {
+	// 50% chance per level
+	if d.Rng.Intn(100) >= 50 {
+		return
+	}
+	
+	room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) == rune(Floor) && d.GetItemAt(x, y) == nil {
+		d.AddItem(NewTorch(x, y))
+	}
+}
+
+// addScroll has a 10% chance of placing a scroll
+func (d *Dungeon) addScroll() {
+	if d.Rng.Intn(100) >= 10 {
+		return
+	}
+	
+	room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) == rune(Floor) && d.GetItemAt(x, y) == nil {
+		d.AddItem(NewScroll(x, y, ScrollType(d.Rng.Intn(len(scrolls)))))
+	}
+}
+
+// addWand has a 10% chance of placing a wand with 3 to 6 charges
+func (d *Dungeon) addWand() {
+	if d.Rng.Intn(100) >= 10 {
+		return
+	}
+	
+	room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) == rune(Floor) && d.GetItemAt(x, y) == nil {
+		d.AddItem(NewWand(x, y, WandType(d.Rng.Intn(len(wands))), 3+d.Rng.Intn(4)))
+	}
+}
+
+// addEquipment has a 30% chance of placing a weapon or armor, whose rarity
+// improves with depth
+func (d *Dungeon) addEquipment() {
+	if d.Rng.Intn(100) >= 30 {
+		return
+	}
+	
+	room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) != rune(Floor) || d.GetItemAt(x, y) != nil {
+		return
+	}
+	
+	rarity := rollRarity(d.Rng, d.Level, Common)
+	var item Item
+	if d.Rng.Intn(2) == 0 {
+		kind := weaponTypes[d.Rng.Intn(len(weaponTypes))]
+		item = NewWeapon(x, y, kind.name, kind.value, rarity)
+		
+		// One weapon in eight is forged from ice
+		if d.Rng.Intn(8) == 0 {
+			frost(&item)
+		}
+	} else {
+		kind := armorTypes[d.Rng.Intn(len(armorTypes))]
+		item = NewArmor(x, y, kind.name, kind.value, kind.slot, rarity)
+		
+		// One piece of armor in four belongs to a set
+		if d.Rng.Intn(4) == 0 {
+			tagSet(&item, armorSets[d.Rng.Intn(len(armorSets))])
+		}
+	}
+	
+	// One piece in ten is cursed
+	if d.Rng.Intn(10) == 0 {
+		curse(&item)
+	}
+	d.AddItem(item)
+}
+
+// addAccessory has a 15% chance of placing a ring or, more rarely, a pendant
+func (d *Dungeon) addAccessory() {
+	if d.Rng.Intn(100) >= 15 {
+		return
+	}
+	
+	room := d.Rooms[d.Rng.Intn(len(d.Rooms))]
+	x := room.X + d.Rng.Intn(room.Width)
+	y := room.Y + d.Rng.Intn(room.Height)
+	if d.at(x, y) != rune(Floor) || d.GetItemAt(x, y) != nil {
+		return
+	}
+	
+	var item Item
+	if d.Rng.Intn(3) > 0 {
+		item = NewRing(x, y, ringTypes[d.Rng.Intn(len(ringTypes))])
+	} else {
+		item = NewPendant(x, y, pendantTypes[d.Rng.Intn(len(pendantTypes))])
+	}
+	
+	// One accessory in ten is cursed
+	if d.Rng.Intn(10) == 0 {
+		curse(&item)
+	}
+	d.AddItem(item)
+}
+
+// addStairs puts the stairs down in the middle of the last room the player
+// doesn't start in. On a level with only the starting room they go in the
+// corner farthest from the start instead.
+func (d *Dungeon) addStairs() {
+	if len(d.Rooms) == 0 {
+		return
+	}
+	first := d.Rooms[0]
+	startX, startY := first.X+first.Width/2, first.Y+first.Height/2 // Where newPlayerIn puts the player
+	start := d.GetRoomAt(startX, startY)
+	for i := len(d.Rooms) - 1; i >= 0; i-- {
+		if room := &d.Rooms[i]; room != start {
+			d.set(room.X+room.Width/2, room.Y+room.Height/2, rune(StairsDown))
+			return
+		}
+	}
+	
+	x, y := first.X, first.Y
+	if startX-first.X < first.X+first.Width-1-startX {
+		x = first.X + first.Width - 1
+	}
+	if startY-first.Y < first.Y+first.Height-1-startY {
+		y = first.Y + first.Height - 1
+	}
+	d.set(x, y, rune(StairsDown))
+}
+
+// addAmulet places the Amulet of Yendor somewhere in the last room, with a
+// boss standing guard over it
+func (d *Dungeon) addAmulet() {
+	room := d.Rooms[len(d.Rooms)-1]
+	
+	// Fall back to the room's corner if no free spot turns up
+	x, y := room.X, room.Y
+	for attempts := 0; attempts < 50; attempts++ {
+		ax := room.X + d.Rng.Intn(room.Width)
+		ay := room.Y + d.Rng.Intn(room.Height)
+		if d.at(ax, ay) == rune(Floor) && d.GetItemAt(ax, ay) == nil {
+			x, y = ax, ay
+			break
+		}
+	}
+	d.AddItem(NewAmulet(x, y))
+	d.spawnBoss(x, y)
+}
+
 // addTraps adds dangerous traps to the dungeon
-func (d *Dungeon) addTraps() {
+func (d *Dungeon) addTraps(minTraps, maxTraps int) {
 	// Add some traps in corridors and rooms
-	numTraps := 2 + rand.Intn(4) // 2-5 traps
+	numTraps := minTraps + d.Rng.Intn(maxTraps-minTraps+1)
 	
 	for i := 0; i < numTraps; i++ {
 		// Try to place a trap
 		for attempts := 0; attempts < 50; attempts++ {
-			x := 1 + rand.Intn(d.Width-2)
-			y := 1 + rand.Intn(d.Height-2)
+			x := 1 + d.Rng.Intn(d.Width-2)
+			y := 1 + d.Rng.Intn(d.Height-2)
 			
-			// Only place traps on floor tiles
-			if d.Grid[y][x] == rune(Floor) {
-				d.Grid[y][x] = rune(Trap)
+			// Only place traps on empty floor tiles, hidden from view
+			if d.at(x, y) == rune(Floor) && d.Traps[[2]int{x, y}] == nil {
+				trap := &TrapState{Hidden: true}
+				switch roll := d.Rng.Intn(100); {
+				case roll < d.Config.PitTrapChance:
+					trap.Kind = TrapPit
+				case roll < d.Config.PitTrapChance+d.Config.AlarmTrapChance:
+					trap.Kind = TrapAlarm
+				}
+				d.Traps[[2]int{x, y}] = trap
 				break
 			}
 		}
 	}
 }
 
-// spawnEnemies creates enemies in the dungeon
-func (d *Dungeon) spawnEnemies(min, max int) {
-	numEnemies := min + rand.Intn(max-min+1)
-	
-	enemyTypes := []struct {
-		name   string
-		symbol rune
-		health int
-		damage int
-	}{
-		{"Goblin", 'g', 3, 1},
-		{"Orc", 'o', 5, 2},
-		{"Troll", 'T', 8, 3},
-		{"Rat", 'r', 1, 1},
-		{"Skeleton", 's', 4, 2},
-	}
+// spawnEnemies creates enemies in the dungeon from the theme's enemy pool
+func (d *Dungeon) spawnEnemies(min, max int, theme Theme) {
+	numEnemies := min + d.Rng.Intn(max-min+1)
 	
 	// Spawn enemies in rooms (not the first room, which is the player's starting point)
 	for i := 0; i < numEnemies; i++ {
@@ -301,43 +834,71 @@ func (d *Dungeon) spawnEnemies(min, max int) {
 		}
 		
 		// Choose a random room (not the first one)
-		roomIndex := 1 + rand.Intn(len(d.Rooms)-1)
+		roomIndex := 1 + d.Rng.Intn(len(d.Rooms)-1)
 		room := d.Rooms[roomIndex]
 		
-		// Choose a random position in the room
-		x := room.X + rand.Intn(room.Width)
-		y := room.Y + rand.Intn(room.Height)
+		// Choose a random free position in the room, giving up after a few tries
+		x, y, ok := d.freeSpotIn(room)
+		if !ok {
+			continue
+		}
 		
-		// Choose a random enemy type
-		enemyType := enemyTypes[rand.Intn(len(enemyTypes))]
+		// Choose a random enemy type from the theme
+		enemyType := enemyTypes[theme.pickEnemy(d.Rng, d.Level)]
 		
-		// Create the enemy
-		enemy := &Enemy{
-			X:       x,
-			Y:       y,
-			Health:  enemyType.health,
-			Symbol:  enemyType.symbol,
-			Name:    enemyType.name,
-			Damage:  enemyType.damage,
-			Hostile: true,
+		// Create the enemy, maybe as an elite, and add it to the enemies list
+		enemy := enemyType.spawn(x, y)
+		rollModifiers(enemy, d.Rng, d.Level)
+		d.AddEnemy(enemy)
+	}
+}
+
+// freeSpotTries is how many random tiles freeSpotIn tries before giving up
+const freeSpotTries = 10
+
+// freeSpotIn picks a random tile in the room that no enemy stands on
+func (d *Dungeon) freeSpotIn(room Room) (int, int, bool) {
+	for i := 0; i < freeSpotTries; i++ {
+		x := room.X + d.Rng.Intn(room.Width)
+		y := room.Y + d.Rng.Intn(room.Height)
+		if d.GetEnemyAt(x, y) == nil {
+			return x, y, true
 		}
-		
-		// Add to enemies list
-		d.Enemies = append(d.Enemies, enemy)
+	}
+	return 0, 0, false
+}
+
+// inBounds reports whether (x, y) lies inside the dungeon
+func (d *Dungeon) inBounds(x, y int) bool {
+	return x >= 0 && y >= 0 && x < d.Width && y < d.Height
+}
+
+// at returns the rune stored at (x, y), or a wall if it is out of bounds
+func (d *Dungeon) at(x, y int) rune {
+	if !d.inBounds(x, y) {
+		return rune(Wall)
+	}
+	return d.Grid[y*d.Width+x]
+}
+
+// set stores a rune at (x, y), ignoring positions out of bounds
+func (d *Dungeon) set(x, y int, r rune) {
+	if d.inBounds(x, y) {
+		d.Grid[y*d.Width+x] = r
 	}
 }
 
 // IsWalkable checks whether the (x, y) position is within bounds and walkable
 func (d *Dungeon) IsWalkable(x, y int) bool {
 	// Check bounds
-	if x < 0 || y < 0 || x >= d.Width || y >= d.Height {
+	if !d.inBounds(x, y) {
 		return false // Out of bounds
 	}
 	
 	// Check tile type
-	tile := TileType(d.Grid[y][x])
+	tile := TileType(d.at(x, y))
 	switch tile {
-	case Floor, Door, Treasure, Trap, StairsDown:
+	case Floor, Door, Treasure, Trap, StairsDown, Teleporter:
 		return true // These tiles are walkable
 	default:
 		return false // Walls and other tiles are not walkable
@@ -346,101 +907,412 @@ func (d *Dungeon) IsWalkable(x, y int) bool {
 
 // GetTileAt returns the tile type at the given coordinates
 func (d *Dungeon) GetTileAt(x, y int) TileType {
-	if x < 0 || y < 0 || x >= d.Width || y >= d.Height {
-		return Wall // Out of bounds is treated as wall
+	return TileType(d.at(x, y)) // Out of bounds is treated as wall
+}
+
+// AddEnemy places an enemy in the dungeon, returning false and leaving it
+// out if another enemy already stands there
+func (d *Dungeon) AddEnemy(enemy *Enemy) bool {
+	if d.GetEnemyAt(enemy.X, enemy.Y) != nil {
+		return false
 	}
-	return TileType(d.Grid[y][x])
+	d.Enemies = append(d.Enemies, enemy)
+	d.enemyAt[[2]int{enemy.X, enemy.Y}] = enemy
+	return true
 }
 
 // GetEnemyAt returns the enemy at the given coordinates, or nil if none
 func (d *Dungeon) GetEnemyAt(x, y int) *Enemy {
-	for _, enemy := range d.Enemies {
-		if enemy.X == x && enemy.Y == y && enemy.Health > 0 {
-			return enemy
-		}
+	if enemy := d.enemyAt[[2]int{x, y}]; enemy != nil && enemy.Health > 0 {
+		return enemy
 	}
 	return nil
 }
 
+// moveEnemy moves an enemy to (x, y), keeping the position index up to date
+func (d *Dungeon) moveEnemy(enemy *Enemy, x, y int) {
+	if d.enemyAt[[2]int{enemy.X, enemy.Y}] == enemy {
+		delete(d.enemyAt, [2]int{enemy.X, enemy.Y})
+	}
+	enemy.X, enemy.Y = x, y
+	d.enemyAt[[2]int{x, y}] = enemy
+}
+
+// AddItem places an item in the dungeon. If another item already lies there,
+// the new one is found once the first has been picked up.
+func (d *Dungeon) AddItem(item Item) {
+	d.Items = append(d.Items, item)
+	pos := [2]int{item.X, item.Y}
+	if d.GetItemAt(item.X, item.Y) == nil {
+		d.itemAt[pos] = len(d.Items) - 1
+	}
+}
+
 // GetItemAt returns the item at the given coordinates, or nil if none
 func (d *Dungeon) GetItemAt(x, y int) *Item {
+	pos := [2]int{x, y}
+	i, ok := d.itemAt[pos]
+	if !ok {
+		return nil
+	}
+	if !d.Items[i].Collected {
+		return &d.Items[i]
+	}
+	
+	// The indexed item was picked up; look for another one on the same tile
+	delete(d.itemAt, pos)
 	for i, item := range d.Items {
 		if item.X == x && item.Y == y && !item.Collected {
+			d.itemAt[pos] = i
 			return &d.Items[i]
 		}
 	}
 	return nil
 }
 
+// Describe prints what can be seen at the given coordinates
+func (d *Dungeon) Describe(x, y int, p *Player) {
+	// Describe the terrain
+	tile := d.GetTileAt(x, y)
+	if trap := d.GetTrapAt(x, y); trap != nil && !trap.Hidden {
+		d.Messages.Log("You see: %s", trap.Kind.Name())
+	} else {
+		d.Messages.Log("You see: %s", tile.Name())
+	}
+	
+	// Describe any enemy standing there, unless it passes for treasure
+	if enemy := d.GetEnemyAt(x, y); enemy != nil && !enemy.Disguised {
+		if enemy.Boss {
+			d.Messages.Log("%s (%c) %d/%d health.", enemy.Name, enemy.Symbol, enemy.Health, enemy.MaxHealth)
+		} else {
+			d.Messages.Log("A %s (%c) %d/%d health.", enemy.Name, enemy.Symbol, enemy.Health, enemy.MaxHealth)
+		}
+		if enemy.RegenPerTurn > 0 {
+			d.Messages.Log("Its wounds close before your eyes (+%d health per turn).", enemy.RegenPerTurn)
+		}
+		if enemy.FrozenTurns > 0 {
+			d.Messages.Log("It is frozen solid.")
+		} else if enemy.SlowTurns > 0 {
+			d.Messages.Log("It is moving sluggishly.")
+		}
+		if resistances := describeResistances(enemy.Resistances); resistances != "" {
+			d.Messages.Log(resistances)
+		}
+	}
+	
+	// Describe any item lying there
+	if item := d.GetItemAt(x, y); item != nil {
+		if !p.Identities.Identified(*item) {
+			d.Messages.Log("A %s.", p.Identities.Name(*item))
+		} else if item.Description != "" {
+			d.Messages.Log("A %s: %s.", item.Name, item.Description)
+		} else {
+			d.Messages.Log("A %s.", item.Name)
+		}
+		if comparison := p.Compare(*item); comparison != "" {
+			d.Messages.Log(comparison)
+		}
+	}
+}
+
+// GetTrapAt returns the trap at the given coordinates, hidden or not, or nil if none
+func (d *Dungeon) GetTrapAt(x, y int) *TrapState {
+	return d.Traps[[2]int{x, y}]
+}
+
+// RevealTrap makes a hidden trap visible on the grid
+func (d *Dungeon) RevealTrap(x, y int) {
+	if trap := d.GetTrapAt(x, y); trap != nil && trap.Hidden {
+		trap.Hidden = false
+		trap.Under = d.at(x, y)
+		d.set(x, y, rune(Trap))
+	}
+}
+
+// RemoveTrap removes a trap from the dungeon, putting back the terrain a
+// revealed trap was drawn over
+func (d *Dungeon) RemoveTrap(x, y int) {
+	trap := d.GetTrapAt(x, y)
+	if trap == nil {
+		return
+	}
+	delete(d.Traps, [2]int{x, y})
+	if trap.Hidden {
+		return // The grid still shows what lies beneath
+	}
+	under := trap.Under
+	if under == 0 || under == rune(Trap) {
+		under = rune(Floor)
+	}
+	d.set(x, y, under)
+}
+
 // RemoveEnemy removes a dead enemy from the dungeon
 func (d *Dungeon) RemoveEnemy(enemy *Enemy) {
 	for i, e := range d.Enemies {
 		if e == enemy {
-			// Remove from slice
+			// Remove from slice and index
 			d.Enemies = append(d.Enemies[:i], d.Enemies[i+1:]...)
+			if d.enemyAt[[2]int{e.X, e.Y}] == e {
+				delete(d.enemyAt, [2]int{e.X, e.Y})
+			}
 			break
 		}
 	}
 }
 
-// MoveEnemies updates enemy positions based on simple AI
-func (d *Dungeon) MoveEnemies(player *Player) {
+// AttackPlayer deals a roll of the enemy's damage to the player, reduced by the
+// player's defense and resistances, and reports it using the given verb (e.g. "attacks")
+func (e *Enemy) AttackPlayer(p *Player, d *Dungeon, verb string) {
+	damage := resist(rollDamage(d.Rng, e.Damage), e.AttackType, p.Resistances) - p.Defense
+	if damage < 1 {
+		damage = 1 // Minimum damage is 1
+	}
+	
+	p.BreakStealth()
+	d.MakeNoise(e.X, e.Y, fightNoise)
+	if p.Evasion > 0 && d.Rng.Intn(100) < p.Evasion {
+		d.Messages.Log("You dodge %s!", e.Title())
+		return
+	}
+	p.TakeDamage(damage)
+	if e.Venomous {
+		p.Poison(3)
+	}
+	if e.AttackType != Physical {
+		d.Messages.Log("%s %s you for %d %s damage!", capitalize(e.Title()), verb, damage, e.AttackType.Name())
+	} else {
+		d.Messages.Log("%s %s you for %d damage!", capitalize(e.Title()), verb, damage)
+	}
+	
+	// Check if player is defeated
+	if p.Health <= 0 {
+		d.Messages.Log("You have been defeated! Game over.")
+	}
+}
+
+// EnemiesAttack lets every living hostile enemy next to the player attack,
+// or failing that, any companion next to it. Companions killed along the way
+// leave the list, so it goes through a copy.
+func (d *Dungeon) EnemiesAttack(player *Player) {
+	for _, enemy := range append([]*Enemy(nil), d.Enemies...) {
+		if !d.present(enemy) || !enemy.Hostile || enemy.Disguised || enemy.Sluggish() {
+			continue
+		}
+		if distance(enemy.X, enemy.Y, player.X, player.Y) == 1 {
+			enemy.AttackPlayer(player, d, "attacks")
+			continue
+		}
+		for _, ally := range d.Allies() {
+			if distance(enemy.X, enemy.Y, ally.X, ally.Y) == 1 {
+				enemy.AttackAlly(ally, d)
+				break
+			}
+		}
+	}
+}
+
+// Sluggish reports whether a frozen or slowed enemy loses its turn this turn
+func (e *Enemy) Sluggish() bool {
+	return e.FrozenTurns > 0 || e.SlowTurns%2 == 1
+}
+
+// NearestEnemy returns the living, hostile enemy closest to (x, y) and its
+// Manhattan distance, or nil and -1 if there are none. Ties go to the enemy
+// that was spawned first.
+func (d *Dungeon) NearestEnemy(x, y int) (*Enemy, int) {
+	var nearest *Enemy
+	best := -1
 	for _, enemy := range d.Enemies {
-		// Skip dead enemies
-		if enemy.Health <= 0 {
+		if enemy.Health <= 0 || !enemy.Hostile || enemy.Disguised {
+			continue
+		}
+		if dist := distance(x, y, enemy.X, enemy.Y); best < 0 || dist < best {
+			nearest, best = enemy, dist
+		}
+	}
+	return nearest, best
+}
+
+// VisitRoom records that the player has stepped into the room at (x, y),
+// returning true if it's a room they hadn't been in before
+func (d *Dungeon) VisitRoom(x, y int) bool {
+	room := d.GetRoomAt(x, y)
+	if room == nil || d.Visited[room] {
+		return false
+	}
+	d.Visited[room] = true
+	return true
+}
+
+// AlertEnemies sets every hostile enemy within radius of (x, y) hunting the player.
+// Disguised mimics keep waiting for their prey.
+func (d *Dungeon) AlertEnemies(x, y, radius int) {
+	for _, enemy := range d.Enemies {
+		if enemy.Health > 0 && enemy.Hostile && !enemy.Disguised && distance(x, y, enemy.X, enemy.Y) <= radius {
+			enemy.Alerted = true
+		}
+	}
+}
+
+// VisibleEnemy returns a living hostile enemy the player can currently see, or nil if none
+func (d *Dungeon) VisibleEnemy(p *Player) *Enemy {
+	for _, enemy := range d.Enemies {
+		if enemy.Health > 0 && enemy.Hostile && !enemy.Disguised && d.IsLit(enemy.X, enemy.Y, p) {
+			return enemy
+		}
+	}
+	return nil
+}
+
+// MoveEnemies lets every living enemy take its turn. Enemies can leave the
+// list mid-turn (killed, fallen through a pit, or escaped), so it goes
+// through a copy and skips any that are no longer on the level.
+func (d *Dungeon) MoveEnemies(player *Player) {
+	for _, enemy := range append([]*Enemy(nil), d.Enemies...) {
+		// Skip dead and departed enemies
+		if !d.present(enemy) {
 			continue
 		}
 		
-		// Simple AI: Move randomly, but prefer moving toward player if nearby
-		dx, dy := 0, 0
-		
-		// Calculate distance to player
-		distX := player.X - enemy.X
-		distY := player.Y - enemy.Y
-		distance := abs(distX) + abs(distY) // Manhattan distance
+		// Frozen enemies can't act until they thaw
+		if enemy.FrozenTurns > 0 {
+			enemy.FrozenTurns--
+			if enemy.FrozenTurns == 0 && d.IsLit(enemy.X, enemy.Y, player) {
+				d.Messages.Log("%s thaws out.", capitalize(enemy.Title()))
+			}
+			continue
+		}
 		
-		// If player is close (within 5 tiles), move toward them
-		if distance < 5 && enemy.Hostile {
-			// Move in the direction of the player
-			if abs(distX) > abs(distY) {
-				// Move horizontally
-				if distX > 0 {
-					dx = 1
-				} else {
-					dx = -1
-				}
-			} else {
-				// Move vertically
-				if distY > 0 {
-					dy = 1
-				} else {
-					dy = -1
-				}
+		// Slowed enemies lose every other turn
+		if enemy.SlowTurns > 0 {
+			sluggish := enemy.Sluggish()
+			enemy.SlowTurns--
+			if sluggish {
+				continue
 			}
-		} else {
-			// Move randomly
-			if rand.Intn(3) > 0 { // 2/3 chance to move
-				directions := []struct{ dx, dy int }{
-					{0, -1}, {1, 0}, {0, 1}, {-1, 0}, // Up, right, down, left
-				}
-				dir := directions[rand.Intn(len(directions))]
-				dx, dy = dir.dx, dir.dy
+		}
+		
+		// Some enemies heal a little every turn
+		if enemy.RegenPerTurn > 0 && enemy.Health < enemy.MaxHealth {
+			enemy.Health += enemy.RegenPerTurn
+			if enemy.Health > enemy.MaxHealth {
+				enemy.Health = enemy.MaxHealth
 			}
 		}
 		
-		// Check if the new position is valid
-		newX, newY := enemy.X+dx, enemy.Y+dy
+		// Enemies without a behavior fall back to simple melee AI
+		behavior := enemy.Behavior
+		if behavior == nil {
+			behavior = MeleeBehavior{}
+		}
+		behavior.TakeTurn(enemy, d, player)
+		if enemy.Fast && d.present(enemy) {
+			behavior.TakeTurn(enemy, d, player)
+		}
+	}
+}
+
+// present reports whether the enemy is alive and still on the level
+func (d *Dungeon) present(enemy *Enemy) bool {
+	return enemy.Health > 0 && d.enemyAt[[2]int{enemy.X, enemy.Y}] == enemy
+}
+
+// stepEnemy moves an enemy one step in the given direction if the destination is free
+func (d *Dungeon) stepEnemy(enemy *Enemy, dir Direction, player *Player) {
+	// Check if the new position is valid
+	dx, dy := dir.Delta()
+	newX, newY := enemy.X+dx, enemy.Y+dy
+	
+	// Don't move onto the player
+	if newX == player.X && newY == player.Y {
+		return
+	}
+	
+	// Check if the new position is walkable for this enemy
+	if d.canWalk(enemy, newX, newY) && d.GetEnemyAt(newX, newY) == nil {
+		d.moveEnemy(enemy, newX, newY)
+		d.enemyEntered(enemy, player)
+	}
+}
+
+// enemyEntered reacts to the tile an enemy just moved onto, the way
+// CheckPosition does for the player. Traps go off and are used up, unless
+// the enemy flies over them, and teleporters send it to their partner. The
+// player only hears about it if they can see the tile.
+func (d *Dungeon) enemyEntered(enemy *Enemy, player *Player) {
+	x, y := enemy.X, enemy.Y
+	seen := d.IsLit(x, y, player)
+	
+	if TileType(d.at(x, y)) == Teleporter {
+		to := d.Teleporters[[2]int{x, y}]
+		if d.GetEnemyAt(to[0], to[1]) == nil && (to[0] != player.X || to[1] != player.Y) {
+			d.moveEnemy(enemy, to[0], to[1])
+			if seen || d.IsLit(to[0], to[1], player) {
+				d.Messages.Log("%s vanishes into a teleporter!", capitalize(enemy.Title()))
+			}
+		}
+		return
+	}
+	
+	trap := d.GetTrapAt(x, y)
+	if trap == nil || enemy.Flying {
+		return
+	}
+	d.RemoveTrap(x, y)
+	
+	switch trap.Kind {
+	case TrapPit:
+		// Gone to the level below, for good
+		d.RemoveEnemy(enemy)
+		if seen {
+			d.Messages.Log("%s falls through a pit!", capitalize(enemy.Title()))
+		}
 		
-		// Don't move onto the player
-		if newX == player.X && newY == player.Y {
-			continue
+	case TrapAlarm:
+		d.MakeNoise(x, y, alarmRadius)
+		if seen {
+			d.Messages.Log("%s sets off an alarm! Something stirs in the dark.", capitalize(enemy.Title()))
+		} else {
+			d.Messages.Log("You hear an alarm blare somewhere on the level.")
 		}
 		
-		// Check if the new position is walkable
-		if d.IsWalkable(newX, newY) && d.GetEnemyAt(newX, newY) == nil {
-			enemy.X, enemy.Y = newX, newY
+	default:
+		damage := d.Config.TrapDamageMin + d.Rng.Intn(d.Config.TrapDamageMax-d.Config.TrapDamageMin+1)
+		enemy.Health -= damage
+		if seen {
+			d.Messages.Log("%s steps on a trap and takes %d damage!", capitalize(enemy.Title()), damage)
+		}
+		if enemy.Health <= 0 {
+			player.DefeatEnemy(enemy, d)
+		}
+	}
+}
+
+// canWalk checks whether the enemy can move onto (x, y). Behaviors that
+// implement Walker decide for themselves; everyone else needs a walkable tile.
+func (d *Dungeon) canWalk(enemy *Enemy, x, y int) bool {
+	if walker, ok := enemy.Behavior.(Walker); ok {
+		return walker.CanWalk(d, x, y)
+	}
+	return d.IsWalkable(x, y)
+}
+
+// Incorporeal reports whether the enemy can drift through walls
+func (e *Enemy) Incorporeal() bool {
+	_, ok := e.Behavior.(GhostBehavior)
+	return ok
+}
+
+// hasClearShot checks that nothing blocks the line between two points
+func (d *Dungeon) hasClearShot(x1, y1, x2, y2 int) bool {
+	path := line(x1, y1, x2, y2)
+	for _, pos := range path[1 : len(path)-1] {
+		if !d.IsWalkable(pos[0], pos[1]) || d.GetEnemyAt(pos[0], pos[1]) != nil {
+			return false
 		}
 	}
+	return true
 }
 
 // abs returns the absolute value of x
@@ -451,30 +1323,212 @@ func abs(x int) int {
 	return x
 }
 
-// Print renders the dungeon grid, displaying the player, enemies, and items
-func (d *Dungeon) Print(p *Player) {
-	// Print the dungeon level
-	fmt.Printf("Dungeon Level: %d\n", d.Level)
+// line returns the points on a straight line from (x0, y0) to (x1, y1)
+// using Bresenham's algorithm, including both end points
+func line(x0, y0, x1, y1 int) [][2]int {
+	points := [][2]int{}
 	
-	// Print the grid
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	
+	for {
+		points = append(points, [2]int{x0, y0})
+		if x0 == x1 && y0 == y1 {
+			break
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+	
+	return points
+}
+
+// IsLit checks whether the (x, y) position is within the player's light
+func (d *Dungeon) IsLit(x, y int, p *Player) bool {
+	radius := p.SightRadius(d.Level)
+	dx, dy := x-p.X, y-p.Y
+	return dx*dx+dy*dy <= radius*radius
+}
+
+// themedRune returns the symbol to draw for the terrain at (x, y) using the level's theme
+func (d *Dungeon) themedRune(x, y int) rune {
+	switch TileType(d.at(x, y)) {
+	case Wall:
+		return d.Theme.WallSymbol
+	case Floor:
+		return d.Theme.FloorSymbol
+	default:
+		return d.at(x, y)
+	}
+}
+
+// DisplayRune returns the symbol to show at (x, y), marking lit tiles as explored
+func (d *Dungeon) DisplayRune(x, y int, p *Player) rune {
+	// Tiles outside the light are only remembered, never seen
+	if !d.Revealed && !d.IsLit(x, y, p) {
+		if d.Explored[y][x] {
+			return d.themedRune(x, y)
+		}
+		return ' '
+	}
+	d.Explored[y][x] = true
+	
+	// Check if there's an enemy at this position
+	if enemy := d.GetEnemyAt(x, y); enemy != nil && enemy.Disguised {
+		return rune(Treasure)
+	} else if enemy != nil {
+		return enemy.Symbol
+	}
+	
+	// Check if player is at this position
+	if p.X == x && p.Y == y {
+		return '@' // Player's position
+	}
+	
+	// Flames hide whatever lies beneath them
+	if d.Burning(x, y) {
+		return fireSymbol
+	}
+	
+	// Check if there's an item lying at this position
+	if item := d.GetItemAt(x, y); item != nil {
+		return item.Symbol
+	}
+	
+	// Otherwise show the terrain
+	return d.themedRune(x, y)
+}
+
+// GetRoomAt returns the room containing (x, y), or nil if the tile lies in a
+// corridor or wall. A room's own tiles run from X to X+Width-1 and Y to
+// Y+Height-1; the walls and doors around it are not part of it.
+func (d *Dungeon) GetRoomAt(x, y int) *Room {
+	for i := range d.Rooms {
+		room := &d.Rooms[i]
+		if x >= room.X && x < room.X+room.Width && y >= room.Y && y < room.Y+room.Height {
+			return room
+		}
+	}
+	return nil
+}
+
+// RandomFloor picks a floor tile free of enemies, traps, and the player,
+// returning false if there is none. Tiles outside the player's current room
+// are preferred, so the player really ends up somewhere else.
+func (d *Dungeon) RandomFloor(p *Player) (int, int, bool) {
+	var free, elsewhere [][2]int
+	here := d.GetRoomAt(p.X, p.Y)
 	for y := 0; y < d.Height; y++ {
 		for x := 0; x < d.Width; x++ {
-			// Check if there's an enemy at this position
-			enemy := d.GetEnemyAt(x, y)
-			if enemy != nil {
-				fmt.Print(string(enemy.Symbol))
+			if d.at(x, y) != rune(Floor) || d.GetEnemyAt(x, y) != nil || d.Traps[[2]int{x, y}] != nil {
 				continue
 			}
-			
-			// Check if player is at this position
-			if p.X == x && p.Y == y {
-				fmt.Print("@") // Player's position
+			if x == p.X && y == p.Y {
 				continue
 			}
-			
-			// Otherwise print the terrain
-			fmt.Print(string(d.Grid[y][x]))
+			free = append(free, [2]int{x, y})
+			if here == nil || d.GetRoomAt(x, y) != here {
+				elsewhere = append(elsewhere, [2]int{x, y})
+			}
+		}
+	}
+	if len(elsewhere) > 0 {
+		free = elsewhere
+	}
+	if len(free) == 0 {
+		return 0, 0, false
+	}
+	pos := free[d.Rng.Intn(len(free))]
+	return pos[0], pos[1], true
+}
+
+// MapLevel marks the whole level as explored, showing its layout and stairs
+// without revealing the enemies and items out of sight
+func (d *Dungeon) MapLevel() {
+	for y := range d.Explored {
+		for x := range d.Explored[y] {
+			d.Explored[y][x] = true
+		}
+	}
+}
+
+// Reveal marks the whole level as explored and makes every enemy and item
+// visible. It is meant for debugging level generation.
+func (d *Dungeon) Reveal() {
+	d.MapLevel()
+	d.Revealed = true
+}
+
+// DumpGrid writes the raw terrain of the level, including hidden traps, to a file
+func (d *Dungeon) DumpGrid(path string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dungeon Level: %d (%s)\n", d.Level, d.Theme.Name)
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			if d.Traps[[2]int{x, y}] != nil {
+				b.WriteRune(rune(Trap))
+			} else {
+				b.WriteRune(d.at(x, y))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// ExportMap writes the level as the player knows it to a file, one symbol per
+// tile and one line per row, with the player and the enemies and items in
+// view drawn over the terrain. Terrain uses the plain symbols rather than
+// the theme's, so the map reads the same whatever the level looks like.
+// Known stairs and teleporters show even under an enemy or item, as a map
+// needs them to load.
+func (d *Dungeon) ExportMap(path string, p *Player) error {
+	var b strings.Builder
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			r := d.DisplayRune(x, y, p)
+			if r == d.themedRune(x, y) || (r != '@' && d.Explored[y][x] && d.structural(x, y)) {
+				r = d.at(x, y)
+			}
+			b.WriteRune(r)
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// structural reports whether the tile holds terrain a map file can't load
+// without: the stairs down, or one end of a teleporter pair
+func (d *Dungeon) structural(x, y int) bool {
+	tile := TileType(d.at(x, y))
+	return tile == StairsDown || tile == Teleporter
+}
+
+// Print renders the dungeon grid, displaying the player, enemies, and items
+func (d *Dungeon) Print(w io.Writer, p *Player) {
+	// Print the dungeon level
+	fmt.Fprintf(w, "Dungeon Level: %d (%s)\n", d.Level, d.Theme.Name)
+	
+	// Print the grid
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			fmt.Fprint(w, string(d.DisplayRune(x, y, p)))
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkEnemyIndex fails the test if the position index disagrees with the
+// enemy list: every living enemy must be found at its own tile, alone
+func checkEnemyIndex(t *testing.T, d *Dungeon) {
+	t.Helper()
+	seen := make(map[[2]int]*Enemy)
+	for _, enemy := range d.Enemies {
+		if enemy.Health <= 0 {
+			continue
+		}
+		pos := [2]int{enemy.X, enemy.Y}
+		if other := seen[pos]; other != nil {
+			t.Fatalf("%s and %s both stand at %v", other.Name, enemy.Name, pos)
+		}
+		seen[pos] = enemy
+		if got := d.GetEnemyAt(enemy.X, enemy.Y); got != enemy {
+			t.Fatalf("GetEnemyAt%v = %v, want the %s standing there", pos, got, enemy.Name)
+		}
+	}
+}
+
+// clearEnemies empties the level of enemies, leaving the position index
+// ready for a test to place its own
+func (d *Dungeon) clearEnemies() {
+	d.Enemies = nil
+	d.enemyAt = make(map[[2]int]*Enemy)
+}
+
+func TestEnemyIndexStaysConsistent(t *testing.T) {
+	cfg := DefaultConfig()
+	for seed := int64(1); seed <= 200; seed++ {
+		d := NewDungeon(3, rand.New(rand.NewSource(seed)), cfg)
+		checkEnemyIndex(t, d)
+
+		p := newPlayerIn(d, ClassWarrior)
+		for turn := 0; turn < 10; turn++ {
+			d.MoveEnemies(p)
+			checkEnemyIndex(t, d)
+		}
+
+		if len(d.Enemies) == 0 {
+			continue
+		}
+		gone := d.Enemies[0]
+		d.RemoveEnemy(gone)
+		checkEnemyIndex(t, d)
+		if d.GetEnemyAt(gone.X, gone.Y) == gone {
+			t.Fatalf("seed %d: removed %s is still found at (%d,%d)", seed, gone.Name, gone.X, gone.Y)
+		}
+	}
+}
+
+func TestAddEnemyRefusesOccupiedTile(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.clearEnemies()
+
+	first := &Enemy{Name: "Rat", X: 5, Y: 5, Health: 3, Hostile: true}
+	second := &Enemy{Name: "Orc", X: 5, Y: 5, Health: 8, Hostile: true}
+	if !d.AddEnemy(first) {
+		t.Fatal("AddEnemy refused an empty tile")
+	}
+	if d.AddEnemy(second) {
+		t.Fatal("AddEnemy put a second enemy on an occupied tile")
+	}
+	if got := d.GetEnemyAt(5, 5); got != first || len(d.Enemies) != 1 {
+		t.Fatalf("GetEnemyAt(5,5) = %v with %d enemies, want only the rat", got, len(d.Enemies))
+	}
+}
+
+// countingBehavior counts its turns, and can leave the level on its first one
+type countingBehavior struct {
+	turns map[*Enemy]int
+	leave bool
+}
+
+func (b countingBehavior) TakeTurn(e *Enemy, d *Dungeon, p *Player) {
+	b.turns[e]++
+	if b.leave {
+		d.RemoveEnemy(e)
+	}
+}
+
+func TestMoveEnemiesGivesEachEnemyOneTurn(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.clearEnemies()
+	p := newPlayerIn(d, ClassWarrior)
+
+	turns := make(map[*Enemy]int)
+	leaver := &Enemy{Name: "Thief", X: 1, Y: 1, Health: 5, Behavior: countingBehavior{turns, true}}
+	d.AddEnemy(leaver)
+	var stayers []*Enemy
+	for i := 0; i < 3; i++ {
+		enemy := &Enemy{Name: "Rat", X: 2 + i, Y: 1, Health: 5, Behavior: countingBehavior{turns, false}}
+		d.AddEnemy(enemy)
+		stayers = append(stayers, enemy)
+	}
+
+	d.MoveEnemies(p)
+	for _, enemy := range append(stayers, leaver) {
+		if turns[enemy] != 1 {
+			t.Errorf("%s at (%d,%d) took %d turns, want 1", enemy.Name, enemy.X, enemy.Y, turns[enemy])
+		}
+	}
+}
+
+func BenchmarkNewDungeon(b *testing.B) {
+	cfg := DefaultConfig()
+	cfg.MapWidth, cfg.MapHeight = 200, 200
+	rng := rand.New(rand.NewSource(1))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewDungeon(1, rng, cfg)
+	}
+}
+
+func TestGetRoomAt(t *testing.T) {
+	d := newSolidDungeon(20, 10, 1, rand.New(rand.NewSource(1)), DefaultConfig())
+	left := Room{X: 2, Y: 2, Width: 4, Height: 3}
+	right := Room{X: 12, Y: 2, Width: 5, Height: 5}
+	for _, room := range []Room{left, right} {
+		d.carveRoom(room)
+		d.Rooms = append(d.Rooms, room)
+	}
+	for x := 6; x < 12; x++ {
+		d.set(x, 3, rune(Floor)) // A corridor between them
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want *Room
+	}{
+		{"inside the left room", 3, 3, &d.Rooms[0]},
+		{"inside the right room", 14, 4, &d.Rooms[1]},
+		{"top left corner", 2, 2, &d.Rooms[0]},
+		{"bottom right corner", 5, 4, &d.Rooms[0]},
+		{"right edge", 16, 6, &d.Rooms[1]},
+		{"just past the right edge", 6, 3, nil},
+		{"just below the bottom edge", 3, 5, nil},
+		{"in the corridor", 9, 3, nil},
+		{"in solid rock", 9, 8, nil},
+	}
+	for _, tt := range tests {
+		if got := d.GetRoomAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("%s: GetRoomAt(%d, %d) = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestStairsAwayFromStart(t *testing.T) {
+	cfg := DefaultConfig()
+	for seed := int64(1); seed <= 100; seed++ {
+		d := NewDungeon(1, rand.New(rand.NewSource(seed)), cfg)
+		p := newPlayerIn(d, ClassWarrior)
+		x, y, ok := d.StairsPos()
+		if !ok {
+			t.Fatalf("seed %d: no stairs", seed)
+		}
+		if len(d.Rooms) > 1 && d.GetRoomAt(x, y) == d.GetRoomAt(p.X, p.Y) {
+			t.Errorf("seed %d: the stairs at (%d,%d) are in the starting room", seed, x, y)
+		}
+	}
+
+	// A level with just one room can't help sharing it, but keeps them apart
+	cfg.MinRooms, cfg.MaxRooms = 1, 1
+	for seed := int64(1); seed <= 100; seed++ {
+		d := NewDungeon(1, rand.New(rand.NewSource(seed)), cfg)
+		if len(d.Rooms) != 1 {
+			continue
+		}
+		p := newPlayerIn(d, ClassWarrior)
+		x, y, _ := d.StairsPos()
+		room := d.Rooms[0]
+		if want := (room.Width-1)/2 + (room.Height-1)/2; distance(p.X, p.Y, x, y) < want {
+			t.Errorf("seed %d: the stairs are %d steps from the start, want at least %d", seed, distance(p.X, p.Y, x, y), want)
+		}
+	}
+}
+
+func TestNearestEnemy(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.clearEnemies()
+	if enemy, dist := d.NearestEnemy(10, 10); enemy != nil || dist != -1 {
+		t.Errorf("NearestEnemy with no enemies = %v, %d, want nil, -1", enemy, dist)
+	}
+
+	// The dead, the friendly and the disguised don't count
+	dead := enemyTypes["Rat"].spawn(10, 11)
+	dead.Health = 0
+	friend := enemyTypes["Rat"].spawn(11, 10)
+	friend.Hostile = false
+	mimic := enemyTypes["Mimic"].spawn(9, 10)
+	mimic.Disguised = true
+	for _, enemy := range []*Enemy{dead, friend, mimic} {
+		d.AddEnemy(enemy)
+	}
+	if enemy, dist := d.NearestEnemy(10, 10); enemy != nil || dist != -1 {
+		t.Errorf("NearestEnemy with no hostile enemies = %v, %d, want nil, -1", enemy, dist)
+	}
+
+	// Two enemies three steps away tie; the one spawned first wins
+	first := enemyTypes["Goblin"].spawn(13, 10)
+	second := enemyTypes["Orc"].spawn(10, 7)
+	far := enemyTypes["Troll"].spawn(15, 15)
+	for _, enemy := range []*Enemy{first, second, far} {
+		d.AddEnemy(enemy)
+	}
+	if enemy, dist := d.NearestEnemy(10, 10); enemy != first || dist != 3 {
+		t.Errorf("NearestEnemy = %v, %d, want the %s at distance 3", enemy, dist, first.Name)
+	}
+}
+
+func TestNoTrapUnderFeatures(t *testing.T) {
+	cfg := DefaultConfig()
+	for seed := int64(1); seed <= 1000; seed++ {
+		for level := 1; level <= 5; level++ {
+			d := NewDungeon(level, rand.New(rand.NewSource(seed)), cfg)
+			for pos := range d.Traps {
+				if tile := d.at(pos[0], pos[1]); tile != rune(Floor) {
+					t.Fatalf("seed %d level %d: a trap at %v hides %q", seed, level, pos, tile)
+				}
+			}
+		}
+	}
+}
+
+func TestRemovingTrapRestoresTerrain(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	x, y, ok := d.StairsPos()
+	if !ok {
+		t.Fatal("the level has no stairs")
+	}
+	d.Traps[[2]int{x, y}] = &TrapState{Hidden: true}
+	d.RevealTrap(x, y)
+	if d.GetTileAt(x, y) != Trap {
+		t.Fatalf("a revealed trap shows as %q", d.at(x, y))
+	}
+	d.RemoveTrap(x, y)
+	if sx, sy, ok := d.StairsPos(); !ok || sx != x || sy != y {
+		t.Errorf("the stairs are gone after removing the trap on them")
+	}
+
+	// A hidden trap never changed the grid, so removing it leaves it be
+	d.Traps[[2]int{x, y}] = &TrapState{Hidden: true}
+	d.RemoveTrap(x, y)
+	if d.GetTileAt(x, y) != StairsDown {
+		t.Errorf("removing a hidden trap turned the stairs into %q", d.at(x, y))
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "math/rand"
+
+// enemyModifier is a trait that turns an ordinary enemy into an elite
+type enemyModifier struct {
+	name   string       // Prefix added to the enemy's name
+	weight int          // Relative chance of being rolled
+	apply  func(*Enemy) // Adjusts the enemy's stats
+}
+
+// enemyModifiers lists every trait an elite enemy can roll
+var enemyModifiers = []enemyModifier{
+	{"Fast", 3, func(e *Enemy) { e.Fast = true }},
+	{"Tough", 3, func(e *Enemy) {
+		e.MaxHealth = e.MaxHealth * 3 / 2
+		e.Health = e.MaxHealth
+	}},
+	{"Venomous", 2, func(e *Enemy) { e.Venomous = true }},
+	{"Giant", 2, func(e *Enemy) { e.Damage += 2 }},
+}
+
+// rollModifiers gives a freshly spawned enemy up to two modifiers, with
+// better odds on deeper levels
+func rollModifiers(e *Enemy, rng *rand.Rand, depth int) {
+	chance := 5 + 5*depth
+	weights := make([]int, len(enemyModifiers))
+	for i, modifier := range enemyModifiers {
+		weights[i] = modifier.weight
+	}
+	applied := map[int]bool{}
+	for i := 0; i < 2; i++ {
+		if rng.Intn(100) >= chance {
+			return
+		}
+		pick := weightedPick(rng, weights)
+		if applied[pick] {
+			continue
+		}
+		applied[pick] = true
+		
+		modifier := enemyModifiers[pick]
+		modifier.apply(e)
+		e.Name = modifier.name + " " + e.Name
+		e.Elite = true
+	}
+}
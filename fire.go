@@ -0,0 +1,87 @@
+package main
+
+import "sort"
+
+// fireSymbol is drawn on burning tiles
+const fireSymbol = '}'
+
+const (
+	fireDuration = 4  // Turns a freshly lit fire burns
+	fireDamage   = 2  // Fire damage dealt each turn to whoever stands in the flames
+	fireSpread   = 50 // Percent chance each turn of fire catching on a neighbouring tile
+)
+
+// flammable reports whether fire can burn on the tile at (x, y)
+func (d *Dungeon) flammable(x, y int) bool {
+	switch TileType(d.at(x, y)) {
+	case Floor, Door, Trap:
+		return true
+	}
+	return false
+}
+
+// Ignite sets the tile at (x, y) burning for the given number of turns. Tiles
+// that aren't flammable or have already burnt out don't catch.
+func (d *Dungeon) Ignite(x, y, turns int) {
+	pos := [2]int{x, y}
+	if _, burnt := d.Fires[pos]; burnt || !d.inBounds(x, y) || !d.flammable(x, y) {
+		return
+	}
+	d.Fires[pos] = turns
+}
+
+// Burning reports whether the tile at (x, y) is on fire
+func (d *Dungeon) Burning(x, y int) bool {
+	return d.Fires[[2]int{x, y}] > 0
+}
+
+// UpdateFires burns whoever stands in the flames, lets each fire spread to
+// the tiles around it, weaker than before, and burns fires down by a turn
+func (d *Dungeon) UpdateFires(p *Player) {
+	// Go through the fires in a fixed order so seeded runs replay the same way
+	var burning [][2]int
+	for pos, turns := range d.Fires {
+		if turns > 0 {
+			burning = append(burning, pos)
+		}
+	}
+	sort.Slice(burning, func(i, j int) bool {
+		if burning[i][1] != burning[j][1] {
+			return burning[i][1] < burning[j][1]
+		}
+		return burning[i][0] < burning[j][0]
+	})
+
+	for _, pos := range burning {
+		x, y := pos[0], pos[1]
+		if p.X == x && p.Y == y && p.Health > 0 {
+			damage := resist(fireDamage, Fire, p.Resistances)
+			p.TakeDamage(damage)
+			d.Messages.Log("You are burned for %d damage!", damage)
+			if p.Health <= 0 {
+				d.Messages.Log("You burn to death! Game over.")
+			}
+		}
+		if enemy := d.GetEnemyAt(x, y); enemy != nil && enemy.Health > 0 {
+			damage := resist(fireDamage, Fire, enemy.Resistances)
+			enemy.Health -= damage
+			if d.IsLit(x, y, p) {
+				d.Messages.Log("%s burns for %d damage!", capitalize(enemy.Title()), damage)
+			}
+			if enemy.Health <= 0 {
+				p.DefeatEnemy(enemy, d)
+			}
+		}
+
+		turns := d.Fires[pos]
+		if turns > 1 {
+			for _, dir := range orthogonalDirections {
+				dx, dy := dir.Delta()
+				if d.Rng.Intn(100) < fireSpread {
+					d.Ignite(x+dx, y+dy, turns-1)
+				}
+			}
+		}
+		d.Fires[pos] = turns - 1
+	}
+}
@@ -0,0 +1,946 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Game states
+const (
+	StateMainMenu = iota
+	StatePlaying
+	StateInventory
+	StateGameOver
+	StateVictory
+	StateQuit
+	StateSettings
+)
+
+// Event is something that happened while the game was updated
+type Event struct {
+	Message string // Description of what happened
+}
+
+// Game holds the state of a single play session
+type Game struct {
+	Dungeon         *Dungeon     // Current dungeon level
+	Player          *Player      // The player character
+	State           int          // Current game state
+	Turns           int          // Number of turns played
+	In              *Input       // Where player commands are read from
+	Out             io.Writer    // Where the game is displayed
+	Rng             *rand.Rand   // Source of every random choice in the game
+	Seed            int64        // Seed the current run was generated from
+	SeedText        string       // Text the seed was hashed from, if the player chose one
+	Daily           string       // Date of the daily challenge being played, or "" for a normal game
+	DailyBoard      []DailyEntry // The daily challenge's results once the run is over, best first
+	Config          *Config      // Game balance settings
+	Identities      *Identities  // Disguised names of potions and scrolls this run
+	Deepest         int          // Deepest dungeon level reached this run
+	Class           Class        // Class the player plays, kept when restarting
+	Name            string       // What the player is called, kept when restarting
+	LastCommand     string       // The last command that used a turn, repeated by an empty line
+	InventoryFilter string       // Kind of item the inventory screen shows, or "" for all
+	Debug           bool         // Whether debugging commands such as "reveal" are allowed
+	Recorder        io.Writer    // Where each command is copied for replaying the run, if anywhere
+	MapFile         string       // Map file the first level was loaded from, if any
+	SettingsFile    string       // Where the settings screen saves changes, or "" to keep them to this session
+	Messages        *MessageLog  // Everything that has happened, shared with the player and each level
+	screen          *Renderer    // Draws the map, redrawing only what changed
+}
+
+// NewGame creates a new game on the first dungeon level, with randomness seeded from the clock
+func NewGame(in *Input, out io.Writer, cfg *Config) *Game {
+	return NewGameWithSeed(in, out, cfg, time.Now().UnixNano())
+}
+
+// NewGameWithSeed creates a new game whose dungeon and events all follow from
+// seed, so sharing the seed lets someone else replay the same run
+func NewGameWithSeed(in *Input, out io.Writer, cfg *Config, seed int64) *Game {
+	g := NewGameWithRand(in, out, cfg, rand.New(rand.NewSource(seed)))
+	g.Seed = seed
+	return g
+}
+
+// NewGameWithRand creates a new game that draws every random choice from rng,
+// so a fixed seed always plays out the same way
+func NewGameWithRand(in *Input, out io.Writer, cfg *Config, rng *rand.Rand) *Game {
+	g := &Game{In: in, Out: out, Rng: rng, Config: cfg, Name: defaultName, Messages: NewMessageLog(100), screen: NewRenderer(out)}
+	g.screen.SetColor(cfg.Color)
+	g.reset()
+	return g
+}
+
+// reset starts a fresh run with a new dungeon and player. The run gets its
+// own copy of the config, so a difficulty changed mid-run waits for the next one.
+func (g *Game) reset() {
+	run := *g.Config
+	g.Dungeon = NewDungeon(1, g.Rng, &run)
+	g.Dungeon.Messages = g.Messages
+	g.Player = newPlayerIn(g.Dungeon, g.Class)
+	g.Player.Name = g.Name
+	g.Identities = NewIdentities(g.Rng)
+	g.Player.Identities = g.Identities
+	g.Dungeon.VisitRoom(g.Player.X, g.Player.Y) // No reward for the room you start in
+	g.Deepest = g.Dungeon.Level
+	g.State = StatePlaying
+	g.Turns = 0
+	g.Messages.Log("You enter the %s.", g.Dungeon.Theme.Name)
+}
+
+// StartOn swaps the run's first level for one loaded from a map file,
+// putting the player on its start
+func (g *Game) StartOn(d *Dungeon) {
+	d.Rng, d.Config, d.Messages = g.Rng, g.Dungeon.Config, g.Messages
+	g.Dungeon = d
+	g.Player.X, g.Player.Y = d.Start[0], d.Start[1]
+	d.VisitRoom(d.Start[0], d.Start[1])
+}
+
+// StartOnMap loads the map file at path and starts the run on it
+func (g *Game) StartOnMap(path string) error {
+	level, err := LoadDungeonFromFile(path)
+	if err != nil {
+		return err
+	}
+	g.StartOn(level)
+	g.MapFile = path
+	return nil
+}
+
+// Update applies a single command to the game and returns what happened.
+// Commands carry their arguments, e.g. "x w" to look up or "c 1 d" to cast
+// the first spell to the right.
+func (g *Game) Update(cmd string) []Event {
+	mark := g.Messages.Mark()
+	if g.Recorder != nil {
+		fmt.Fprintln(g.Recorder, cmd)
+	}
+	
+	switch g.State {
+	case StateMainMenu:
+		g.updateMainMenu(cmd)
+	case StateSettings:
+		g.updateSettings(cmd)
+	case StatePlaying:
+		g.updatePlaying(cmd)
+	case StateInventory:
+		g.updateInventory(cmd)
+	case StateGameOver, StateVictory:
+		g.updateGameOver(cmd)
+	}
+	
+	events := []Event{}
+	for _, msg := range g.Messages.Since(mark) {
+		events = append(events, Event{Message: msg})
+	}
+	return events
+}
+
+// updatePlaying handles a command while exploring the dungeon
+func (g *Game) updatePlaying(cmd string) {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		// An empty line repeats the last action
+		if g.LastCommand == "" {
+			return
+		}
+		cmd, fields = g.LastCommand, strings.Fields(g.LastCommand)
+	}
+	command, args := parseCommand(cmd)
+	player, dungeon := g.Player, g.Dungeon
+	
+	// Process the command, noting whether it used up a turn
+	tookTurn := false
+	switch command {
+	case "quit":
+		// Make sure the player means to abandon the run
+		if len(args) == 0 || args[0] != "y" {
+			if len(args) == 0 {
+				g.Messages.Log("Use 'q y' to really quit.")
+			} else {
+				g.Messages.Log("You keep playing.")
+			}
+			break
+		}
+		g.State = StateQuit
+		return
+		
+	case "wait":
+		// Stand still for a turn
+		tookTurn = true
+		
+	case "look":
+		// Examine an adjacent tile (does not use a turn)
+		if dir, ok := g.directionArg(args, 0); ok {
+			dx, dy := dir.Delta()
+			dungeon.Describe(player.X+dx, player.Y+dy, player)
+		}
+		
+	case "cast":
+		// Cast a known spell, aiming it if needed
+		var spellIndex int
+		if len(args) == 0 {
+			g.Messages.Log("Invalid spell selection.")
+			break
+		}
+		if _, err := fmt.Sscanf(args[0], "%d", &spellIndex); err != nil || spellIndex < 1 || spellIndex > len(player.Spells) {
+			g.Messages.Log("Invalid spell selection.")
+			break
+		}
+		spell := player.Spells[spellIndex-1]
+		
+		// Targeted spells need a direction
+		var dir Direction
+		if spells[spell].Targeted {
+			var ok bool
+			if dir, ok = g.directionArg(args, 1); !ok {
+				break
+			}
+		}
+		tookTurn = player.CastSpell(spell, dir, dungeon)
+		
+	case "zap":
+		// Zap a wand from the inventory in a direction
+		var itemIndex int
+		if len(args) == 0 {
+			g.Messages.Log("Invalid item selection.")
+			break
+		}
+		if _, err := fmt.Sscanf(args[0], "%d", &itemIndex); err != nil || itemIndex < 1 || itemIndex > len(player.Inventory) {
+			g.Messages.Log("Invalid item selection.")
+			break
+		}
+		if dir, ok := g.directionArg(args, 1); ok {
+			tookTurn = player.Zap(itemIndex-1, dir, dungeon)
+		}
+		
+	case "search":
+		// Search adjacent tiles for hidden things
+		player.Search(dungeon)
+		tookTurn = true
+		
+	case "disarm":
+		// Try to disarm an adjacent trap
+		if dir, ok := g.directionArg(args, 0); ok {
+			dx, dy := dir.Delta()
+			player.Disarm(player.X+dx, player.Y+dy, dungeon)
+			tookTurn = true
+		}
+		
+	case "inventory":
+		g.State = StateInventory
+		
+	case "flee":
+		// Back away from the nearest enemy without fighting
+		tookTurn = player.Flee(dungeon)
+		
+	case "sneak":
+		// Start or stop sneaking (does not use a turn)
+		player.ToggleSneak()
+		
+	case "menu":
+		// Step out to the main menu; the run waits there to be continued
+		g.State = StateMainMenu
+		
+	case "go":
+		// A direction word or key should follow
+		g.Messages.Log("Go which way? Try 'go north' or 'go w'.")
+		
+	case "use", "drop", "throw":
+		// Handle items by name without opening the inventory; like the
+		// inventory screen, this takes no turn
+		if len(args) == 0 {
+			g.Messages.Log("%s what?", capitalize(command))
+			break
+		}
+		if command == "throw" && len(args) < 2 {
+			g.Messages.Log("Throw it which way? Give the direction last, like 'throw dagger d'.")
+		} else if command == "throw" {
+			dir, ok := parseDirection(args[len(args)-1])
+			if !ok {
+				g.Messages.Log("Invalid direction.")
+			} else if itemIndex, ok := player.FindItem(strings.Join(args[:len(args)-1], " ")); ok {
+				player.ThrowItem(itemIndex, dir, dungeon)
+			}
+		} else if command == "use" {
+			// A trailing 'y' confirms reading a Scroll of Descent mid-fight
+			confirmed := len(args) > 1 && args[len(args)-1] == "y"
+			if confirmed {
+				args = args[:len(args)-1]
+			}
+			if itemIndex, ok := player.FindItem(strings.Join(args, " ")); ok {
+				g.useItem(itemIndex, confirmed)
+			}
+		} else if itemIndex, ok := player.FindItem(strings.Join(args, " ")); ok {
+			player.DropItem(itemIndex, dungeon)
+		}
+		
+	case "quickheal":
+		// Drink the best health potion without opening the inventory,
+		// as freely as using it from there
+		player.QuickHeal(dungeon)
+		
+	case "pickup":
+		// Pick up what's here by hand
+		if player.PickUp(dungeon) {
+			tookTurn = true
+		} else {
+			g.Messages.Log("There is nothing here to pick up.")
+		}
+		
+	case "autopickup":
+		// Toggle picking things up just by stepping on them
+		player.AutoPickup = !player.AutoPickup
+		if player.AutoPickup {
+			g.Messages.Log("Auto-pickup is on.")
+		} else {
+			g.Messages.Log("Auto-pickup is off. Use 'g' to pick things up.")
+		}
+		
+	case "descend":
+		// Make sure the player means to leave mid-fight
+		if g.needsDescendConfirm() && (len(args) == 0 || args[0] != "y") {
+			if len(args) == 0 {
+				g.Messages.Log("Enemies are near. Use '> y' to descend anyway.")
+			} else {
+				g.Messages.Log("You stay where you are.")
+			}
+			break
+		}
+		
+		// Descend if the player is on the stairs
+		if dungeon.GetTileAt(player.X, player.Y) != StairsDown {
+			g.Messages.Log("There are no stairs here.")
+			break
+		}
+		g.Descend()
+		
+	case "travel":
+		// Walk to the stairs down once they have been found
+		if len(args) > 0 && args[0] != ">" && args[0] != "stairs" {
+			g.Messages.Log("Travel where? Use 'travel >' to walk to the stairs down.")
+			break
+		}
+		g.travelToStairs() // Ends its own turns
+		
+	case "rest":
+		// Rest to recover health (with risk), optionally for several turns
+		if len(args) == 0 {
+			tookTurn = rest(player, dungeon) // Enemies still move while resting
+			break
+		}
+		var turns int
+		if args[0] == "full" {
+			turns = maxRestTurns
+		} else if _, err := fmt.Sscan(args[0], &turns); err != nil || turns < 1 {
+			g.Messages.Log("Rest how long? Use 'rest <turns>' or 'rest full'.")
+			break
+		}
+		g.restFor(turns) // Ends its own turns
+		
+	case "repeat":
+		// Carry out a command several times, e.g. "repeat 5 d"
+		var times int
+		if len(args) < 2 {
+			g.Messages.Log("Repeat what? Use 'repeat <times> <command>'.")
+		} else if _, err := fmt.Sscan(args[0], &times); err != nil || times < 1 {
+			g.Messages.Log("Repeat how many times? Use 'repeat <times> <command>'.")
+		} else {
+			g.repeatCommand(min(times, maxRepeats), strings.Join(args[1:], " ")) // Ends its own turns
+		}
+		
+	case "export":
+		// Save the map as far as it's been explored, e.g. to share it
+		path := fmt.Sprintf("dungeon-map-%d.txt", dungeon.Level)
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if err := dungeon.ExportMap(path, player); err != nil {
+			g.Messages.Log("Could not save the map: %v", err)
+		} else {
+			g.Messages.Log("Map saved to %s.", path)
+		}
+		
+	case "reveal":
+		// Debug only: show the whole level and save its layout
+		if !g.Debug {
+			g.Messages.Log("Unknown command. Type '?' or 'help' for instructions.")
+			break
+		}
+		dungeon.Reveal()
+		path := fmt.Sprintf("dungeon-level-%d.txt", dungeon.Level)
+		if err := dungeon.DumpGrid(path); err != nil {
+			g.Messages.Log("Could not save the map: %v", err)
+		} else {
+			g.Messages.Log("The level is revealed. Its layout was saved to %s.", path)
+		}
+		
+	default:
+		// Movement keys come from the key bindings
+		if dir, ok := moveDirections[command]; ok {
+			player.Move(dir, dungeon)
+			tookTurn = true // Enemies move after player
+			if player.Sneaking && !player.sneaksFreely() {
+				g.endTurn() // Sneaking takes twice as long
+			}
+		} else {
+			g.Messages.Log(unknownCommand(fields[0]))
+		}
+	}
+	
+	// A pit trap drops the player before the world reacts, so the turn
+	// plays out on the level they land on
+	if player.Falling {
+		g.Descend()
+	}
+	player.ExploreRoom(g.Dungeon)
+	
+	// Let the world react once per player action
+	if tookTurn {
+		g.LastCommand = cmd
+		g.endTurn()
+	}
+	
+	// Check if player is dead, or has found the amulet. A repeated or
+	// traveled step may already have ended the run and wrapped it up.
+	if g.State != StatePlaying {
+		return
+	}
+	if player.Health <= 0 {
+		g.State = StateGameOver
+		g.finish(false)
+	} else if player.HasItem(ItemAmulet) {
+		g.State = StateVictory
+		g.finish(true)
+	}
+}
+
+// updateInventory handles a command on the inventory screen
+func (g *Game) updateInventory(cmd string) {
+	player := g.Player
+	fields := strings.Fields(cmd)
+	
+	if cmd == "b" || cmd == "back" {
+		g.State = StatePlaying
+	} else if cmd == "sort" {
+		// Group the inventory by type, best items first
+		player.SortInventory()
+		g.Messages.Log("You sort your belongings.")
+	} else if strings.HasPrefix(cmd, "f ") {
+		// Show only one kind of item
+		if filter, ok := parseFilter(strings.TrimSpace(cmd[2:])); ok {
+			g.InventoryFilter = filter
+		} else {
+			g.Messages.Log("Unknown filter. Try one of: %s, or all.", strings.Join(filterNames(), ", "))
+		}
+	} else if len(fields) > 1 && fields[0] == "d" {
+		// Drop an item on the floor
+		if itemIndex, ok := player.FindItem(strings.Join(fields[1:], " ")); ok {
+			player.DropItem(itemIndex, g.Dungeon)
+		}
+	} else if len(fields) > 2 && fields[0] == "t" {
+		// Throw an item in a chosen direction, given last
+		dir, ok := parseDirection(fields[len(fields)-1])
+		if !ok {
+			g.Messages.Log("Invalid direction.")
+		} else if itemIndex, ok := player.FindItem(strings.Join(fields[1:len(fields)-1], " ")); ok {
+			player.ThrowItem(itemIndex, dir, g.Dungeon)
+		}
+	} else if len(fields) > 0 {
+		// Use an item by number or name, a trailing 'y' confirming a
+		// Scroll of Descent with enemies near
+		confirmed := len(fields) > 1 && fields[len(fields)-1] == "y"
+		if confirmed {
+			fields = fields[:len(fields)-1]
+		}
+		if itemIndex, ok := player.FindItem(strings.Join(fields, " ")); ok {
+			g.useItem(itemIndex, confirmed)
+		}
+	} else {
+		g.Messages.Log("Invalid item selection.")
+	}
+}
+
+// updateGameOver handles a command on the game over and victory screens
+func (g *Game) updateGameOver(cmd string) {
+	switch cmd {
+	case "r", "restart":
+		g.restart()
+	case "q", "quit":
+		g.State = StateQuit
+	}
+}
+
+// restart begins a new run. A daily challenge is retried as is; otherwise
+// each new run gets its own seed, drawn from the last one.
+func (g *Game) restart() {
+	if g.Daily == "" {
+		g.Seed = g.Rng.Int63()
+		g.SeedText = ""
+	}
+	g.Rng.Seed(g.Seed)
+	g.reset()
+}
+
+// SeedLabel describes the seed for sharing, including the text it came from if any
+func (g *Game) SeedLabel() string {
+	if g.SeedText != "" {
+		return fmt.Sprintf("%q (%d)", g.SeedText, g.Seed)
+	}
+	return strconv.FormatInt(g.Seed, 10)
+}
+
+// Score rates the current run: gold, kills, and depth all count, and winning earns a bonus
+func (g *Game) Score() int {
+	score := g.Player.Gold + 10*g.Player.Stats.TotalKills() + 50*(g.Dungeon.Level-1)
+	if g.State == StateVictory {
+		score += 500
+	}
+	return score
+}
+
+// finish wraps up a run that has just ended, recording daily challenge results
+func (g *Game) finish(won bool) {
+	if g.Daily == "" {
+		return
+	}
+	entry := DailyEntry{Name: g.Name, Score: g.Score(), Level: g.Dungeon.Level, Turns: g.Turns, Won: won}
+	board, err := recordDailyRun(g.Daily, entry)
+	if err != nil {
+		g.Messages.Log("Could not save the daily leaderboard: %v", err)
+		return
+	}
+	g.DailyBoard = board
+}
+
+// printDailyBoard shows the top daily challenge results, if this is a daily run
+func (g *Game) printDailyBoard() {
+	if g.Daily == "" || len(g.DailyBoard) == 0 {
+		return
+	}
+	fmt.Fprintf(g.Out, "\n=== Daily Challenge %s ===\n", g.Daily)
+	for i, entry := range g.DailyBoard {
+		if i == 5 {
+			break
+		}
+		result := "died"
+		if entry.Won {
+			result = "won"
+		}
+		fmt.Fprintf(g.Out, "%d. %s - %d points (level %d, %d turns, %s)\n", i+1, entry.Player(), entry.Score, entry.Level, entry.Turns, result)
+	}
+}
+
+// endTurn advances the world by one turn after the player acts
+func (g *Game) endTurn() {
+	g.Dungeon.EnemiesAttack(g.Player)
+	g.Dungeon.MoveEnemies(g.Player)
+	g.Dungeon.UpdateBones(g.Player)
+	g.Dungeon.UpdateFires(g.Player)
+	g.Player.UpdateHunger()
+	g.Player.UpdatePoison()
+	g.Player.BurnTorch()
+	g.Player.DetectTraps(g.Dungeon)
+	g.Player.Regenerate()
+	g.Turns++
+}
+
+// enterLevel makes next the current level, rewarding a new depth and
+// bringing along companions near (fromX, fromY), where the player left the
+// last level. The amulet waits on the goal level, or any level below it if
+// it was missed.
+func (g *Game) enterLevel(next *Dungeon, fromX, fromY int) {
+	bringAllies(g.Player, fromX, fromY, g.Dungeon, next)
+	g.Dungeon = next
+	next.VisitRoom(g.Player.X, g.Player.Y)
+	if next.Level > g.Deepest {
+		g.Deepest = next.Level
+		if g.Config.DepthExp > 0 {
+			g.Messages.Log("You have never been this deep before.")
+			g.Player.GainExp(g.Config.DepthExp)
+		}
+	}
+	if next.Level >= g.Config.WinLevel && !g.Player.HasItem(ItemAmulet) {
+		next.addAmulet()
+		if next.Level == g.Config.WinLevel {
+			g.Messages.Log("You sense the Amulet of Yendor is close.")
+		}
+	}
+}
+
+// Descend takes the player down to a new level one deeper than the current
+// one. It is shared by the stairs, pit traps, and the Scroll of Descent:
+// a player falling through a pit lands on a random free floor tile, anyone
+// else starts in the first room. Callers check for nearby enemies first.
+func (g *Game) Descend() {
+	player, x, y := g.Player, g.Player.X, g.Player.Y
+	next := NewDungeon(g.Dungeon.Level+1, g.Dungeon.Rng, g.Dungeon.Config)
+	next.Messages = g.Messages
+	
+	if player.Falling {
+		player.Falling = false
+		if fx, fy, ok := next.RandomFloor(player); ok {
+			player.X, player.Y = fx, fy
+		} else {
+			placeInFirstRoom(player, next)
+		}
+		g.Messages.Log("You land on dungeon level %d.", next.Level)
+	} else {
+		placeInFirstRoom(player, next)
+		g.Messages.Log("You descend to dungeon level %d...", next.Level)
+	}
+	g.Messages.Log("You have entered the %s.", next.Theme.Name)
+	g.enterLevel(next, x, y)
+}
+
+// needsDescendConfirm reports whether the player is on the stairs with a
+// hostile enemy within the configured warning range
+func (g *Game) needsDescendConfirm() bool {
+	return g.Dungeon.GetTileAt(g.Player.X, g.Player.Y) == StairsDown && g.enemyNearby()
+}
+
+// needsUseConfirm reports whether using the item would take the player off
+// the level by magic with a hostile enemy within the warning range. Only
+// scrolls the player has identified ask, since reading an unknown one is a gamble.
+func (g *Game) needsUseConfirm(itemIndex int) bool {
+	item := g.Player.Inventory[itemIndex]
+	return item.Type == ItemScroll && ScrollType(item.Value) == ScrollDescent &&
+		g.Player.Identities.Identified(item) && g.enemyNearby()
+}
+
+// enemyNearby reports whether a hostile enemy is within the configured
+// range for warning the player before they leave the level
+func (g *Game) enemyNearby() bool {
+	warnRange := g.Config.DescendWarnRange
+	if warnRange <= 0 {
+		return false
+	}
+	enemy, dist := g.Dungeon.NearestEnemy(g.Player.X, g.Player.Y)
+	return enemy != nil && dist <= warnRange
+}
+
+// useItem uses an inventory item, holding back from reading a Scroll of
+// Descent with enemies near unless confirmed, and takes the player down a
+// level if the item called for it
+func (g *Game) useItem(itemIndex int, confirmed bool) {
+	if !confirmed && g.needsUseConfirm(itemIndex) {
+		g.Messages.Log("Enemies are near. Add 'y' to read the %s anyway.", g.Player.Inventory[itemIndex].Name)
+		return
+	}
+	g.Player.UseItem(itemIndex, g.Dungeon)
+	if g.Player.Descending {
+		g.Player.Descending = false
+		g.Descend()
+	}
+}
+
+// maxRestTurns caps how long "rest full" can go on
+const maxRestTurns = 200
+
+// restFor rests turn by turn, letting the world move each turn, until the
+// player is fully healed, the given number of turns has passed, or danger appears
+func (g *Game) restFor(turns int) {
+	player, dungeon := g.Player, g.Dungeon
+	for i := 0; i < turns; i++ {
+		if player.Health >= player.MaxHealth {
+			g.Messages.Log("You feel fully rested.")
+			return
+		}
+		if enemy := dungeon.VisibleEnemy(player); enemy != nil {
+			g.Messages.Log("You stop resting: a %s comes into view.", enemy.Name)
+			return
+		}
+		
+		// An interrupted rest doesn't use the turn, but ends the rest
+		if !rest(player, dungeon) {
+			return
+		}
+		health := player.Health
+		g.endTurn()
+		if player.Health < health {
+			g.Messages.Log("You stop resting: something is hurting you!")
+			return
+		}
+	}
+}
+
+// maxRepeats is the most times a single "repeat" can carry out a command
+const maxRepeats = 100
+
+// repeatCommand carries out cmd up to the given number of times, stopping
+// early if it stops using turns, the level changes, the player is hurt, or
+// an enemy is in sight
+func (g *Game) repeatCommand(times int, cmd string) {
+	player := g.Player
+	for i := 0; i < times && g.State == StatePlaying; i++ {
+		health, turns, level := player.Health, g.Turns, g.Dungeon.Level
+		g.updatePlaying(cmd)
+		if g.Turns == turns || g.Dungeon.Level != level || g.State != StatePlaying {
+			return
+		}
+		if player.Health < health {
+			g.Messages.Log("You stop: something is hurting you!")
+			return
+		}
+		if enemy := g.Dungeon.VisibleEnemy(player); enemy != nil && i+1 < times {
+			g.Messages.Log("You stop: you see a %s.", enemy.Name)
+			return
+		}
+	}
+}
+
+// directionArg parses the direction argument at index i, logging a message if it's missing or invalid
+func (g *Game) directionArg(args []string, i int) (Direction, bool) {
+	if i < len(args) {
+		if dir, ok := parseDirection(args[i]); ok {
+			return dir, true
+		}
+	}
+	g.Messages.Log("Invalid direction.")
+	return North, false
+}
+
+// Run plays the game interactively until the player quits
+func (g *Game) Run() {
+	// Make sure the terminal is restored even if the game panics
+	defer func() {
+		g.In.Restore()
+		if r := recover(); r != nil {
+			panic(r)
+		}
+	}()
+	
+	// Display welcome message and instructions
+	fmt.Fprintln(g.Out, "=== Welcome to Dungeon Crawler ===")
+	if g.Daily != "" {
+		fmt.Fprintf(g.Out, "Daily challenge for %s\n", g.Daily)
+	}
+	fmt.Fprintf(g.Out, "Seed: %s\n", g.SeedLabel())
+	printHelp(g.Out)
+	if g.screen.Clears() {
+		g.pause()
+	}
+	
+	// Main game loop
+	for g.State != StateQuit {
+		// Handle different game states
+		switch g.State {
+		case StateMainMenu:
+			// The menu scrolls the map away, so redraw it afterwards
+			g.screen.Invalidate()
+			g.Messages.PrintUnread(g.Out)
+			fmt.Fprintln(g.Out)
+			for _, line := range g.MenuLines() {
+				fmt.Fprintln(g.Out, line)
+			}
+			fmt.Fprint(g.Out, "Choose an option: ")
+			input := g.In.ReadLine()
+			if g.In.Closed() {
+				g.State = StateQuit
+				break
+			}
+			g.Update(input)
+			
+		case StateSettings:
+			g.Messages.PrintUnread(g.Out)
+			fmt.Fprintln(g.Out)
+			for _, line := range g.SettingsLines() {
+				fmt.Fprintln(g.Out, line)
+			}
+			fmt.Fprint(g.Out, "Enter a number to change it, or anything else to go back: ")
+			input := g.In.ReadLine()
+			if g.In.Closed() {
+				g.State = StateQuit
+				break
+			}
+			g.Update(input)
+			
+		case StatePlaying:
+			// Display the dungeon and player status
+			g.screen.Draw(g.Dungeon, g.Player)
+			g.screen.DrawStatus(g.Dungeon, g.Player, g.Turns)
+			g.Messages.PrintRecent(g.Out, 5)
+			
+			// Process player input
+			fmt.Fprint(g.Out, "\nEnter command: ")
+			input := g.In.ReadKey()
+			if g.In.Closed() {
+				g.State = StateQuit // Nothing more to play
+				break
+			}
+			
+			// Help and the message history only affect the display
+			var page int
+			fields := strings.Fields(input)
+			switch {
+			case len(fields) == 1 && commandFor(fields[0]) == "help":
+				printHelp(g.Out)
+				g.pause()
+			case len(fields) == 1 && commandFor(fields[0]) == "legend":
+				g.Dungeon.PrintLegend(g.Out)
+				g.pause()
+			case len(fields) == 1 && commandFor(fields[0]) == "messages":
+				g.showHistory(1)
+			case len(fields) == 2 && commandFor(fields[0]) == "messages" && scanPage(fields[1], &page):
+				g.showHistory(page)
+			default:
+				g.Update(g.completeCommand(input))
+			}
+			
+		case StateInventory:
+			// The inventory scrolls the map away, so redraw it afterwards
+			g.screen.Invalidate()
+			
+			// Show what happened since the last screen, then the inventory
+			g.Messages.PrintUnread(g.Out)
+			fmt.Fprintln(g.Out, "\n=== Inventory ===")
+			g.Player.DisplayInventory(g.Out, g.InventoryFilter, g.screen.Colored())
+			fmt.Fprintln(g.Out, "\nEnter an item's number or name to use it, 't <item>' to throw it, 'd <item>' to drop it, 'sort' to sort, 'f <kind>' to filter, or 'b' to go back:")
+			
+			input := g.In.ReadLine()
+			if g.In.Closed() {
+				g.State = StateQuit
+				break
+			}
+			
+			// Ask where to throw if the direction was left out
+			if fields := strings.Fields(input); len(fields) > 1 && fields[0] == "t" {
+				if _, ok := parseDirection(fields[len(fields)-1]); len(fields) == 2 || !ok {
+					fmt.Fprint(g.Out, "Throw which direction? (w/a/s/d or h/j/k/l): ")
+					input += " " + g.In.ReadKey()
+				}
+			}
+			g.Update(input)
+			
+		case StateGameOver:
+			// Game over screen
+			g.screen.Invalidate()
+			g.Messages.PrintUnread(g.Out)
+			fmt.Fprintln(g.Out, "\n=== GAME OVER ===")
+			fmt.Fprintf(g.Out, "%s died on dungeon level %d after %d turns.\n", g.Name, g.Dungeon.Level, g.Turns)
+			fmt.Fprintf(g.Out, "Gold collected: %d\n", g.Player.Gold)
+			for _, line := range g.Player.Stats.Summary() {
+				fmt.Fprintln(g.Out, line)
+			}
+			fmt.Fprintf(g.Out, "Score: %d | Seed: %s\n", g.Score(), g.SeedLabel())
+			g.printDailyBoard()
+			fmt.Fprintln(g.Out, "\nPress 'r' to restart or 'q' to quit:")
+			
+			key := g.In.ReadKey()
+			if g.In.Closed() {
+				g.State = StateQuit
+				break
+			}
+			g.Update(key)
+			
+		case StateVictory:
+			// Victory screen
+			g.screen.Invalidate()
+			g.Messages.PrintUnread(g.Out)
+			fmt.Fprintln(g.Out, "\n=== VICTORY ===")
+			fmt.Fprintf(g.Out, "%s found the Amulet of Yendor on dungeon level %d after %d turns.\n", g.Name, g.Dungeon.Level, g.Turns)
+			fmt.Fprintf(g.Out, "Gold: %d | Character level: %d\n", g.Player.Gold, g.Player.Level)
+			for _, line := range g.Player.Stats.Summary() {
+				fmt.Fprintln(g.Out, line)
+			}
+			fmt.Fprintf(g.Out, "Score: %d | Seed: %s\n", g.Score(), g.SeedLabel())
+			g.printDailyBoard()
+			fmt.Fprintln(g.Out, "\nPress 'r' to play again or 'q' to quit:")
+			
+			key := g.In.ReadKey()
+			if g.In.Closed() {
+				g.State = StateQuit
+				break
+			}
+			g.Update(key)
+		}
+	}
+	
+	fmt.Fprintln(g.Out, "Thanks for playing! Goodbye!")
+}
+
+// showHistory prints a page of the message log and waits for the player to read it
+func (g *Game) showHistory(page int) {
+	g.Messages.PrintPage(g.Out, page, 10)
+	g.pause()
+}
+
+// pause waits for the player to finish reading a screen of text
+func (g *Game) pause() {
+	fmt.Fprint(g.Out, "Press Enter to continue...")
+	g.In.ReadKey()
+	g.screen.Invalidate()
+}
+
+// completeCommand prompts for any arguments a command needs but was typed without
+func (g *Game) completeCommand(input string) string {
+	fields := strings.Fields(input)
+	if len(fields) != 1 {
+		return input
+	}
+	
+	switch commandFor(fields[0]) {
+	case "look":
+		fmt.Fprint(g.Out, "Look which direction? (w/a/s/d or h/j/k/l): ")
+		return input + " " + g.In.ReadKey()
+		
+	case "disarm":
+		fmt.Fprint(g.Out, "Disarm which direction? (w/a/s/d or h/j/k/l): ")
+		return input + " " + g.In.ReadKey()
+		
+	case "descend":
+		if g.needsDescendConfirm() {
+			fmt.Fprint(g.Out, "Enemies are near - descend anyway? (y/n): ")
+			return input + " " + g.In.ReadKey()
+		}
+		
+	case "quit":
+		fmt.Fprint(g.Out, "Really quit? Unsaved progress will be lost (y/n): ")
+		return input + " " + g.In.ReadKey()
+		
+	case "zap":
+		// Choose a wand and a direction
+		fmt.Fprintln(g.Out, "Wands:")
+		for i, item := range g.Player.Inventory {
+			if item.Type == ItemWand {
+				fmt.Fprintf(g.Out, "%d. %s\n", i+1, g.Player.ItemLabel(item))
+			}
+		}
+		fmt.Fprint(g.Out, "Zap which wand? ")
+		input += " " + g.In.ReadKey()
+		fmt.Fprint(g.Out, "Zap which direction? (w/a/s/d or h/j/k/l): ")
+		return input + " " + g.In.ReadKey()
+		
+	case "cast":
+		// Choose a known spell to cast
+		fmt.Fprintln(g.Out, "Known spells:")
+		for i, spell := range g.Player.Spells {
+			info := spells[spell]
+			fmt.Fprintf(g.Out, "%d. %s (%d mana) - %s\n", i+1, info.Name, info.Cost, info.Description)
+		}
+		fmt.Fprint(g.Out, "Cast which spell? ")
+		choice := g.In.ReadKey()
+		input += " " + choice
+		
+		// Targeted spells need a direction
+		var spellIndex int
+		if _, err := fmt.Sscanf(choice, "%d", &spellIndex); err == nil && spellIndex >= 1 && spellIndex <= len(g.Player.Spells) {
+			if spells[g.Player.Spells[spellIndex-1]].Targeted {
+				fmt.Fprint(g.Out, "Cast which direction? (w/a/s/d or h/j/k/l): ")
+				input += " " + g.In.ReadKey()
+			}
+		}
+	}
+	return input
+}
+
+// scanPage parses a message history page number
+func scanPage(s string, page *int) bool {
+	_, err := fmt.Sscan(s, page)
+	return err == nil
+}
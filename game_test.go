@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// playScript runs the game on seed 1 with the given lines as its input,
+// returning the game and everything it printed. It fails the test if the
+// game doesn't end by itself once the script runs out.
+func playScript(t *testing.T, script string) (*Game, string) {
+	t.Helper()
+	var out bytes.Buffer
+	in := NewInput(strings.NewReader(script), &out, false)
+	g := NewGameWithSeed(in, &out, DefaultConfig(), 1)
+	g.State = StateMainMenu
+
+	done := make(chan struct{})
+	go func() {
+		g.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the game was still running after the script ended")
+	}
+	return g, out.String()
+}
+
+// inTempDir runs the rest of the test in a fresh directory, so files the
+// game saves don't land in the repository, and returns the directory
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestScriptedMoves(t *testing.T) {
+	start := NewGameWithSeed(nil, nil, DefaultConfig(), 1).Player
+	g, out := playScript(t, "1\n.\n.\n.\nq y\n")
+
+	if g.State != StateQuit {
+		t.Errorf("state = %d after quitting, want StateQuit", g.State)
+	}
+	if g.Turns != 3 {
+		t.Errorf("played %d turns, want 3", g.Turns)
+	}
+	if g.Player.X != start.X || g.Player.Y != start.Y {
+		t.Errorf("waiting moved the player from (%d,%d) to (%d,%d)", start.X, start.Y, g.Player.X, g.Player.Y)
+	}
+	for _, want := range []string{"=== Main Menu ===", "Turn: 3", "Thanks for playing! Goodbye!"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q", want)
+		}
+	}
+}
+
+func TestScriptEndingOnEveryScreenQuits(t *testing.T) {
+	scripts := map[string]string{
+		"main menu": "",
+		"playing":   "1\n",
+		"inventory": "1\ni\n",
+		"settings":  "3\n",
+	}
+	for name, script := range scripts {
+		t.Run(name, func(t *testing.T) {
+			g, _ := playScript(t, script)
+			if g.State != StateQuit {
+				t.Errorf("state = %d, want StateQuit", g.State)
+			}
+		})
+	}
+}
+
+func TestScriptEndingAfterDeathQuits(t *testing.T) {
+	var out bytes.Buffer
+	g := NewGameWithSeed(NewInput(strings.NewReader(""), &out, false), &out, DefaultConfig(), 1)
+	g.State = StateGameOver
+
+	done := make(chan struct{})
+	go func() {
+		g.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the game over screen kept waiting after the input ran out")
+	}
+}
+
+func TestDyingMidRepeatRecordsDailyRunOnce(t *testing.T) {
+	inTempDir(t)
+	g := NewGameWithSeed(nil, io.Discard, DefaultConfig(), 1)
+	g.Daily = "2026-01-01"
+	d, p := g.Dungeon, g.Player
+	d.clearEnemies()
+	for _, dir := range orthogonalDirections {
+		dx, dy := dir.Delta()
+		killer := enemyTypes["Troll"].spawn(p.X+dx, p.Y+dy)
+		killer.Damage = 1000
+		d.AddEnemy(killer)
+	}
+
+	g.Update("repeat 5 .")
+	if g.State != StateGameOver {
+		t.Fatalf("state = %d after waiting among trolls, want StateGameOver", g.State)
+	}
+	board, err := loadDailyBoard()
+	if err != nil {
+		t.Fatalf("loading the daily board: %v", err)
+	}
+	if runs := len(board[g.Daily]); runs != 1 {
+		t.Errorf("the run was recorded %d times, want once", runs)
+	}
+}
+
+func TestThreeDescentsReachLevelFour(t *testing.T) {
+	var out bytes.Buffer
+	g := NewGameWithSeed(nil, &out, DefaultConfig(), 1)
+	for i := 0; i < 3; i++ {
+		d := g.Dungeon
+		d.clearEnemies()
+		x, y, ok := d.StairsPos()
+		if !ok {
+			t.Fatalf("level %d has no stairs", d.Level)
+		}
+		g.Player.X, g.Player.Y = x, y
+		g.Update(">")
+	}
+
+	if g.Dungeon.Level != 4 || g.Deepest != 4 {
+		t.Errorf("after three descents the level is %d and the deepest %d, want 4", g.Dungeon.Level, g.Deepest)
+	}
+	if last := strings.Join(g.Messages.Last(5), "\n"); !strings.Contains(last, "dungeon level 4") {
+		t.Errorf("recent messages don't mention level 4:\n%s", last)
+	}
+	g.screen.Draw(g.Dungeon, g.Player)
+	if !strings.Contains(out.String(), "Dungeon Level: 4") {
+		t.Error("the map header doesn't show level 4")
+	}
+}
+
+func TestGamesKeepSeparateMessages(t *testing.T) {
+	a := NewGameWithSeed(nil, io.Discard, DefaultConfig(), 1)
+	b := NewGameWithSeed(nil, io.Discard, DefaultConfig(), 1)
+	before := b.Messages.Mark()
+
+	a.Dungeon.clearEnemies()
+	x, y, _ := a.Dungeon.StairsPos()
+	a.Player.X, a.Player.Y = x, y
+	a.Update(">")
+	a.ChooseClass(ClassMage)
+	if a.Dungeon.Messages != a.Messages || a.Player.Messages != a.Messages {
+		t.Error("the new level or player doesn't log to the game's messages")
+	}
+	if got := b.Messages.Since(before); len(got) != 0 {
+		t.Errorf("another game's messages leaked in: %q", got)
+	}
+}
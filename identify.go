@@ -0,0 +1,86 @@
+package main
+
+import "math/rand"
+
+// Appearances that potions and scrolls are disguised with until identified
+var (
+	potionLooks  = []string{"murky", "bubbling", "glowing", "cloudy", "fizzy", "oily", "smoky", "golden"}
+	scrollLabels = []string{"XYZZY", "FOOBIE BLETCH", "ELBIB YLOH", "ZELGO MER", "DAIYEN FOOELS", "KERNOD WEL", "PRATYAVAYAH"}
+)
+
+// Identities maps the true names of potions and scrolls to the disguised
+// names they go by this run, and records which ones the player has learned
+type Identities struct {
+	Appearances map[string]string // Unidentified name of each kind, keyed by its true name
+	Known       map[string]bool   // True names of the kinds the player has identified
+}
+
+// NewIdentities gives every kind of potion and scroll a random disguise for a new run
+func NewIdentities(rng *rand.Rand) *Identities {
+	ids := &Identities{
+		Appearances: make(map[string]string),
+		Known:       make(map[string]bool),
+	}
+	
+	looks := rng.Perm(len(potionLooks))
+	for i, name := range []string{NewHealthPotion(0, 0).Name, NewFirePotion(0, 0).Name, NewStrengthPotion(0, 0).Name} {
+		ids.Appearances[name] = potionLooks[looks[i]] + " potion"
+	}
+	
+	labels := rng.Perm(len(scrollLabels))
+	for kind := ScrollType(0); int(kind) < len(scrolls); kind++ {
+		ids.Appearances[NewScroll(0, 0, kind).Name] = "scroll labeled " + scrollLabels[labels[kind]]
+	}
+	return ids
+}
+
+// Identified reports whether the player knows what the item really is
+func (ids *Identities) Identified(item Item) bool {
+	_, disguised := ids.Appearances[item.Name]
+	return !disguised || ids.Known[item.Name]
+}
+
+// Identify reveals the true name of every item of the same kind, returning
+// true if it was not already known
+func (ids *Identities) Identify(item Item) bool {
+	if ids.Identified(item) {
+		return false
+	}
+	ids.Known[item.Name] = true
+	return true
+}
+
+// Name returns what the player calls the item
+func (ids *Identities) Name(item Item) string {
+	if ids.Identified(item) {
+		return item.Name
+	}
+	return ids.Appearances[item.Name]
+}
+
+// Description returns what the player knows the item does
+func (ids *Identities) Description(item Item) string {
+	if ids.Identified(item) {
+		return item.Description
+	}
+	return "unidentified"
+}
+
+// identify identifies an item for the player, announcing what it turned out to be
+func (p *Player) identify(item Item) bool {
+	if !p.Identities.Identify(item) {
+		return false
+	}
+	p.Messages.Log("The %s is a %s!", p.Identities.Appearances[item.Name], item.Name)
+	return true
+}
+
+// identifyInventory identifies the first unknown kind of item the player is carrying
+func (p *Player) identifyInventory() {
+	for _, item := range p.Inventory {
+		if p.identify(item) {
+			return
+		}
+	}
+	p.Messages.Log("You feel as if you already know everything you carry.")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Input reads player commands, either one line at a time or, when single-key
+// mode is enabled on a terminal, one keypress at a time without Enter
+type Input struct {
+	reader *bufio.Reader
+	echo   io.Writer // Where typed keys are echoed in single-key mode
+	fd     int
+	keys   bool        // Whether single-key mode is active
+	state  *term.State // Saved terminal state while a key is being read
+	closed bool        // Whether the input has run out
+}
+
+// NewInput creates an input reader that echoes keys to the given writer. Single-key
+// mode is only used when requested and the reader is a terminal; anything else,
+// such as a pipe or a scripted command list, falls back to lines.
+func NewInput(r io.Reader, echo io.Writer, singleKey bool) *Input {
+	in := &Input{
+		reader: bufio.NewReader(r),
+		echo:   echo,
+		fd:     -1,
+	}
+	if f, ok := r.(*os.File); ok {
+		in.fd = int(f.Fd())
+		in.keys = singleKey && term.IsTerminal(in.fd)
+	}
+	return in
+}
+
+// ReadLine reads a full line of input, without surrounding whitespace
+func (in *Input) ReadLine() string {
+	line, err := in.reader.ReadString('\n')
+	if err != nil && line == "" {
+		in.closed = true
+	}
+	return strings.TrimSpace(line)
+}
+
+// Closed reports whether the input has run out, such as at the end of a piped script
+func (in *Input) Closed() bool {
+	return in.closed
+}
+
+// ReadKey reads a single command key. In line mode it reads a whole line instead.
+// Typing ':' in single-key mode allows entering a longer command such as "disarm".
+func (in *Input) ReadKey() string {
+	if !in.keys {
+		return in.ReadLine()
+	}
+	
+	// Only keep the terminal raw while waiting for the key, so that
+	// everything the game prints still gets normal line handling
+	state, err := term.MakeRaw(in.fd)
+	if err != nil {
+		return in.ReadLine()
+	}
+	in.state = state
+	r, _, err := in.reader.ReadRune()
+	if err != nil {
+		in.Restore()
+		in.closed = true
+		return ""
+	}
+	key := in.decodeKey(r)
+	in.Restore()
+	
+	// Echo the key, or switch to line entry for long commands
+	if key == ":" {
+		fmt.Fprint(in.echo, ":")
+		return in.ReadLine()
+	}
+	fmt.Fprintln(in.echo, key)
+	return key
+}
+
+// decodeKey turns a key read in single-key mode into a command key, reading
+// the rest of an arrow key's escape sequence. An Escape that doesn't start
+// a sequence is the Escape key itself, leaving any key typed after it unread.
+func (in *Input) decodeKey(r rune) string {
+	switch r {
+	case 3: // Ctrl-C
+		return "q"
+	case '\r', '\n':
+		return ""
+	case 27:
+		if in.reader.Buffered() == 0 {
+			return "escape"
+		}
+		if next, err := in.reader.Peek(1); err != nil || next[0] != '[' {
+			return "escape"
+		}
+		in.reader.ReadRune() // The '['
+		arrow, _, _ := in.reader.ReadRune()
+		return map[rune]string{'A': "up", 'B': "down", 'C': "right", 'D': "left"}[arrow]
+	}
+	return string(r)
+}
+
+// Restore puts the terminal back into its normal mode if a key read was interrupted
+func (in *Input) Restore() {
+	if in.state != nil {
+		term.Restore(in.fd, in.state)
+		in.state = nil
+	}
+}
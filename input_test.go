@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// decode reads the first key from the given bytes the way single-key mode
+// does, returning it along with whatever input is left unread
+func decode(t *testing.T, typed string) (string, string) {
+	t.Helper()
+	in := NewInput(strings.NewReader(typed), io.Discard, false)
+	r, _, err := in.reader.ReadRune()
+	if err != nil {
+		t.Fatalf("reading %q: %v", typed, err)
+	}
+	key := in.decodeKey(r)
+	rest, _ := io.ReadAll(in.reader)
+	return key, string(rest)
+}
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		typed, key, rest string
+	}{
+		{"w", "w", ""},
+		{"\x03", "q", ""},
+		{"\r", "", ""},
+		{"\x1b[A", "up", ""},
+		{"\x1b[D", "left", ""},
+		{"\x1b", "escape", ""},
+		{"\x1bw", "escape", "w"},
+	}
+	for _, tt := range tests {
+		key, rest := decode(t, tt.typed)
+		if key != tt.key || rest != tt.rest {
+			t.Errorf("%q decoded to %q leaving %q, want %q leaving %q", tt.typed, key, rest, tt.key, tt.rest)
+		}
+	}
+}
+
+func TestLoneEscapeOpensMenu(t *testing.T) {
+	key, _ := decode(t, "\x1b")
+	if cmd := commandFor(key); cmd != "menu" {
+		t.Errorf("Escape maps to %q, want menu", cmd)
+	}
+}
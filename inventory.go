@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// inventoryFilters maps each inventory filter to the item types it shows
+var inventoryFilters = map[string][]ItemType{
+	"potions":     {ItemPotion, ItemFirePotion, ItemStrengthPotion},
+	"scrolls":     {ItemScroll, ItemSpellbook},
+	"weapons":     {ItemWeapon},
+	"armor":       {ItemArmor},
+	"accessories": {ItemRing, ItemPendant},
+	"wands":       {ItemWand},
+	"food":        {ItemFood},
+	"bombs":       {ItemBomb},
+}
+
+// filterNames lists the inventory filters in a stable order for help text
+func filterNames() []string {
+	names := make([]string, 0, len(inventoryFilters))
+	for name := range inventoryFilters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchesFilter reports whether an item is shown by the inventory filter;
+// an empty filter shows everything
+func matchesFilter(item Item, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	for _, t := range inventoryFilters[filter] {
+		if item.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFilter reads a filter name, accepting "all" to clear the filter
+func parseFilter(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if name == "all" {
+		return "", true
+	}
+	_, ok := inventoryFilters[name]
+	return name, ok
+}
+
+// FindItem picks an inventory item by its number or by part of its name,
+// ignoring case, returning its 0-based index. Several different matching
+// items are ambiguous, and the player is asked to be more specific.
+func (p *Player) FindItem(query string) (int, bool) {
+	var index int
+	if _, err := fmt.Sscanf(query, "%d", &index); err == nil {
+		if index < 1 || index > len(p.Inventory) {
+			p.Messages.Log("Invalid item selection.")
+			return 0, false
+		}
+		return index - 1, true
+	}
+
+	// Collect every distinct name that matches, remembering the first item of each
+	query = strings.ToLower(strings.TrimSpace(query))
+	var names []string
+	first := map[string]int{}
+	for i, item := range p.Inventory {
+		name := p.Identities.Name(item)
+		if query == "" || !strings.Contains(strings.ToLower(name), query) {
+			continue
+		}
+		if _, seen := first[name]; !seen {
+			first[name] = i
+			names = append(names, name)
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		p.Messages.Log("You aren't carrying anything like that.")
+		return 0, false
+	case 1:
+		return first[names[0]], true
+	default:
+		p.Messages.Log("Which do you mean: %s?", strings.Join(names, ", "))
+		return 0, false
+	}
+}
+
+// SortInventory orders the inventory by item type, then by value from best
+// to worst, then by name
+func (p *Player) SortInventory() {
+	sort.SliceStable(p.Inventory, func(i, j int) bool {
+		a, b := p.Inventory[i], p.Inventory[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if shownValue(a) != shownValue(b) {
+			return shownValue(a) > shownValue(b)
+		}
+		return p.Identities.Name(a) < p.Identities.Name(b)
+	})
+}
@@ -1,5 +1,10 @@
 package main
 
+import (
+	"math/rand"
+	"strconv"
+)
+
 // ItemType represents different types of items
 type ItemType int
 
@@ -10,8 +15,131 @@ const (
 	ItemArmor
 	ItemTreasure
 	ItemKey
+	ItemFirePotion
+	ItemSpellbook
+	ItemFood
+	ItemTorch
+	ItemAmulet
+	ItemRing
+	ItemPendant
+	ItemScroll
+	ItemWand
+	ItemStrengthPotion
+	ItemBomb
 )
 
+// ScrollType is the magic written on a scroll
+type ScrollType int
+
+const (
+	ScrollMagicMapping ScrollType = iota
+	ScrollTeleport
+	ScrollIdentify
+	ScrollRemoveCurse
+	ScrollDescent
+)
+
+// scrollInfo describes a kind of scroll
+type scrollInfo struct {
+	Name        string
+	Description string
+}
+
+// scrolls holds the details of every kind of scroll
+var scrolls = map[ScrollType]scrollInfo{
+	ScrollMagicMapping: {"Magic Mapping", "Reveals the layout of the current level"},
+	ScrollTeleport:     {"Teleport", "Moves you to a random spot on the current level"},
+	ScrollIdentify:     {"Identify", "Reveals what one unknown item you carry really is"},
+	ScrollRemoveCurse:  {"Remove Curse", "Lifts the curse from everything you wear"},
+	ScrollDescent:      {"Descent", "Sinks you through the floor to the next level, stairs or not"},
+}
+
+// Rarity is how rare and powerful a piece of equipment is
+type Rarity int
+
+const (
+	Common Rarity = iota
+	Uncommon
+	Rare
+	Epic
+)
+
+// Name returns the rarity's name, used as a prefix for item names
+func (r Rarity) Name() string {
+	switch r {
+	case Uncommon:
+		return "Uncommon"
+	case Rare:
+		return "Rare"
+	case Epic:
+		return "Epic"
+	default:
+		return "Common"
+	}
+}
+
+// bonus returns how much the rarity adds to an item's base value
+func (r Rarity) bonus() int {
+	return []int{0, 1, 2, 4}[r]
+}
+
+// rollRarity picks a rarity, with better odds on deeper levels, that is at least min
+func rollRarity(rng *rand.Rand, depth int, min Rarity) Rarity {
+	// A roll of 0-99 shifted up by depth*5: 65 and up is Uncommon, 90 and
+	// up Rare, and 110 and up Epic. Each rarity is weighted by how much of
+	// the shifted range falls in its band.
+	low, high := depth*5, depth*5+100
+	band := func(from, to int) int {
+		if to > high {
+			to = high
+		}
+		if from < low {
+			from = low
+		}
+		return max(to-from, 0)
+	}
+	weights := []int{band(-1<<30, 65), band(65, 90), band(90, 110), band(110, 1<<30)}
+	rarity := Rarity(weightedPick(rng, weights))
+	if rarity < min {
+		rarity = min
+	}
+	return rarity
+}
+
+// rarityName prefixes a name with the rarity, leaving common items plain
+func rarityName(name string, rarity Rarity) string {
+	if rarity == Common {
+		return name
+	}
+	return rarity.Name() + " " + name
+}
+
+// ArmorSlot is the part of the body a piece of armor protects
+type ArmorSlot int
+
+const (
+	SlotHead ArmorSlot = iota
+	SlotBody
+	SlotFeet
+)
+
+// armorSlots lists every slot in the order they are displayed
+var armorSlots = []ArmorSlot{SlotHead, SlotBody, SlotFeet}
+
+// Name returns a human-readable name for the slot
+func (s ArmorSlot) Name() string {
+	switch s {
+	case SlotHead:
+		return "Head"
+	case SlotBody:
+		return "Body"
+	case SlotFeet:
+		return "Feet"
+	default:
+		return "Unknown"
+	}
+}
+
 // Item represents an item in the game
 type Item struct {
 	X, Y        int      // Position in the dungeon
@@ -20,6 +148,15 @@ type Item struct {
 	Description string   // Description of the item
 	Value       int      // Value (gold, healing amount, damage, etc.)
 	Symbol      rune     // Symbol to display on the map
+	Weight      int      // How heavy the item is to carry
+	Element     DamageType // Damage type dealt by weapons and thrown potions
+	Rarity      Rarity   // How rare the item is; scales weapon and armor stats
+	Slot        ArmorSlot // Where armor is worn
+	Effect      AccessoryEffect // Passive bonus of a ring or pendant
+	Cursed      bool     // Cursed equipment has a hidden flaw and can't be taken off
+	Charges     int      // Zaps left in a wand
+	SetName     string   // Armor set the item belongs to, if any
+	Listed      int      // Value a cursed item appears to have
 	Collected   bool     // Whether the item has been collected
 }
 
@@ -33,38 +170,181 @@ func NewHealthPotion(x, y int) Item {
 		Description: "Restores 10 health points",
 		Value:      10,
 		Symbol:     '!',
+		Weight:     1,
+		Collected:  false,
+	}
+}
+
+// NewFirePotion creates a new potion of fire, meant to be thrown at enemies
+func NewFirePotion(x, y int) Item {
+	return Item{
+		X:          x,
+		Y:          y,
+		Type:       ItemFirePotion,
+		Name:       "Potion of Fire",
+		Element:    Fire,
+		Description: "Bursts into flames when thrown, dealing 6 damage",
+		Value:      6,
+		Symbol:     '!',
+		Weight:     1,
+		Collected:  false,
+	}
+}
+
+// NewStrengthPotion creates a new potion of strength, which permanently
+// raises the drinker's attack
+func NewStrengthPotion(x, y int) Item {
+	return Item{
+		X:          x,
+		Y:          y,
+		Type:       ItemStrengthPotion,
+		Name:       "Potion of Strength",
+		Description: "Permanently increases attack by 1",
+		Value:      1,
+		Symbol:     '!',
+		Weight:     1,
 		Collected:  false,
 	}
 }
 
-// NewWeapon creates a new weapon
-func NewWeapon(x, y int, name string, damage int) Item {
+// NewSpellbook creates a new spellbook that teaches the given spell
+func NewSpellbook(x, y int, spell SpellType) Item {
+	return Item{
+		X:          x,
+		Y:          y,
+		Type:       ItemSpellbook,
+		Name:       "Spellbook of " + spells[spell].Name,
+		Description: spells[spell].Description,
+		Value:      int(spell),
+		Symbol:     '?',
+		Weight:     3,
+		Collected:  false,
+	}
+}
+
+// NewFood creates a new food ration
+func NewFood(x, y int) Item {
+	return Item{
+		X:          x,
+		Y:          y,
+		Type:       ItemFood,
+		Name:       "Food Ration",
+		Description: "Satisfies 150 turns of hunger",
+		Value:      150,
+		Symbol:     '%',
+		Weight:     2,
+		Collected:  false,
+	}
+}
+
+// NewTorch creates a new torch
+func NewTorch(x, y int) Item {
+	return Item{
+		X:          x,
+		Y:          y,
+		Type:       ItemTorch,
+		Name:       "Torch",
+		Description: "Lights up a wider area for 100 turns",
+		Value:      100,
+		Symbol:     '~',
+		Weight:     3,
+		Collected:  false,
+	}
+}
+
+// NewScroll creates a new scroll of the given kind
+func NewScroll(x, y int, kind ScrollType) Item {
+	return Item{
+		X:          x,
+		Y:          y,
+		Type:       ItemScroll,
+		Name:       "Scroll of " + scrolls[kind].Name,
+		Description: scrolls[kind].Description,
+		Value:      int(kind),
+		Symbol:     '&',
+		Weight:     1,
+		Collected:  false,
+	}
+}
+
+// NewWeapon creates a new weapon, its damage scaled by its rarity
+func NewWeapon(x, y int, name string, damage int, rarity Rarity) Item {
+	damage += rarity.bonus()
 	return Item{
 		X:          x,
 		Y:          y,
 		Type:       ItemWeapon,
-		Name:       name,
-		Description: "Increases attack by " + string(damage),
+		Name:       rarityName(name, rarity),
+		Description: "Increases attack by " + strconv.Itoa(damage),
 		Value:      damage,
 		Symbol:     '/',
+		Weight:     8,
+		Rarity:     rarity,
 		Collected:  false,
 	}
 }
 
-// NewArmor creates a new armor
-func NewArmor(x, y int, name string, defense int) Item {
+// NewArmor creates a new piece of armor for the given slot, its defense scaled by its rarity
+func NewArmor(x, y int, name string, defense int, slot ArmorSlot, rarity Rarity) Item {
+	defense += rarity.bonus()
 	return Item{
 		X:          x,
 		Y:          y,
 		Type:       ItemArmor,
-		Name:       name,
-		Description: "Increases defense by " + string(defense),
+		Name:       rarityName(name, rarity),
+		Description: slot.Name() + " armor, increases defense by " + strconv.Itoa(defense),
 		Value:      defense,
 		Symbol:     '[',
+		Weight:     slotWeights[slot],
+		Rarity:     rarity,
+		Slot:       slot,
 		Collected:  false,
 	}
 }
 
+// curse secretly ruins a piece of equipment while leaving its name and
+// description as tempting as ever: weapons barely cut, and armor and
+// accessories hurt rather than help
+func curse(item *Item) {
+	item.Cursed = true
+	item.Listed = item.Value
+	if item.Type == ItemWeapon {
+		item.Value = 1
+	} else {
+		item.Value = -item.Value
+	}
+}
+
+// frost turns a weapon into an ice weapon, whose blows can freeze enemies solid
+func frost(item *Item) {
+	item.Element = Ice
+	item.Name = "Frost " + item.Name
+	item.Description += ", and its icy blows may freeze enemies solid"
+}
+
+// cursedDescription is what a cursed item is known as once it reveals itself
+const cursedDescription = "Cursed! It can't be removed"
+
+// equipmentType describes the base stats of a kind of weapon or armor
+type equipmentType struct {
+	name  string
+	value int
+	slot  ArmorSlot // Only used by armor
+}
+
+// weaponTypes and armorTypes list the equipment that can be found in the dungeon
+var (
+	weaponTypes = []equipmentType{{"Short Sword", 4, 0}, {"Longsword", 5, 0}, {"Battle Axe", 6, 0}}
+	armorTypes  = []equipmentType{
+		{"Leather Cap", 1, SlotHead}, {"Iron Helm", 2, SlotHead},
+		{"Leather Armor", 2, SlotBody}, {"Chain Mail", 3, SlotBody}, {"Plate Armor", 4, SlotBody},
+		{"Leather Boots", 1, SlotFeet}, {"Iron Greaves", 2, SlotFeet},
+	}
+)
+
+// slotWeights is how heavy armor for each slot is to carry
+var slotWeights = map[ArmorSlot]int{SlotHead: 4, SlotBody: 12, SlotFeet: 4}
+
 // NewGold creates a new gold pile
 func NewGold(x, y int, amount int) Item {
 	return Item{
@@ -72,9 +352,10 @@ func NewGold(x, y int, amount int) Item {
 		Y:          y,
 		Type:       ItemGold,
 		Name:       "Gold",
-		Description: "Worth " + string(amount) + " gold",
+		Description: "Worth " + strconv.Itoa(amount) + " gold",
 		Value:      amount,
 		Symbol:     '$',
+		Weight:     0,
 		Collected:  false,
 	}
 }
@@ -86,9 +367,25 @@ func NewKey(x, y int) Item {
 		Y:          y,
 		Type:       ItemKey,
 		Name:       "Key",
-		Description: "Can unlock doors",
+		Description: "Can unlock a chest",
 		Value:      1,
 		Symbol:     'k',
+		Weight:     1,
+		Collected:  false,
+	}
+}
+
+// NewAmulet creates the Amulet of Yendor, the object of the player's quest
+func NewAmulet(x, y int) Item {
+	return Item{
+		X:          x,
+		Y:          y,
+		Type:       ItemAmulet,
+		Name:       "Amulet of Yendor",
+		Description: "The treasure you came here for",
+		Value:      0,
+		Symbol:     '"',
+		Weight:     1,
 		Collected:  false,
 	}
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// KeyBindings maps each command name to the keys that trigger it
+type KeyBindings map[string][]string
+
+// defaultKeyBindings are used for any command not rebound in keys.json
+var defaultKeyBindings = KeyBindings{
+	"move_up":    {"w", "up", "k"},
+	"move_down":  {"s", "down", "j"},
+	"move_left":  {"a", "left", "h"},
+	"move_right": {"d", "right", "l"},
+	"wait":       {".", "wait"},
+	"look":       {"x", "look"},
+	"cast":       {"c", "cast"},
+	"zap":        {"z", "zap"},
+	"search":     {"f", "search"},
+	"disarm":     {"D", "disarm"},
+	"inventory":  {"i", "inventory"},
+	"quickheal":  {"H", "quickheal"},
+	"flee":       {"F", "flee"},
+	"sneak":      {"n", "sneak"},
+	"menu":       {"M", "menu", "escape"},
+	"pickup":     {"g", "pickup"},
+	"autopickup": {"autopickup"},
+	"descend":    {">"},
+	"travel":     {"T", "travel"},
+	"rest":       {"r", "rest"},
+	"messages":   {"m", "messages"},
+	"help":       {"?", "help"},
+	"legend":     {"L", "legend"},
+	"export":     {"export"},
+	"quit":       {"q", "quit"},
+	"reveal":     {"reveal"},
+}
+
+// moveDirections gives the direction each movement command moves in
+var moveDirections = map[string]Direction{
+	"move_up":    North,
+	"move_down":  South,
+	"move_left":  West,
+	"move_right": East,
+}
+
+// commandKeys maps each bound key to its command name
+var commandKeys = indexKeys(defaultKeyBindings)
+
+// LoadKeyBindings reads key bindings from a JSON file mapping command names to
+// lists of keys, e.g. {"move_up": ["w", "up"]}. Commands missing from the file
+// keep their default keys, and a missing file leaves every default in place.
+func LoadKeyBindings(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	
+	var custom KeyBindings
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return err
+	}
+	
+	// Start from the defaults and replace the rebound commands
+	bindings := KeyBindings{}
+	for command, keys := range defaultKeyBindings {
+		bindings[command] = keys
+	}
+	for command, keys := range custom {
+		if _, ok := defaultKeyBindings[command]; !ok {
+			return fmt.Errorf("unknown command %q", command)
+		}
+		bindings[command] = keys
+	}
+	
+	// The same key can't trigger two commands
+	seen := map[string]string{}
+	for command, keys := range bindings {
+		for _, key := range keys {
+			if other, ok := seen[key]; ok {
+				return fmt.Errorf("key %q is bound to both %s and %s", key, other, command)
+			}
+			seen[key] = command
+		}
+	}
+	
+	commandKeys = indexKeys(bindings)
+	return nil
+}
+
+// indexKeys builds the key to command lookup for a set of bindings
+func indexKeys(bindings KeyBindings) map[string]string {
+	index := map[string]string{}
+	for command, keys := range bindings {
+		for _, key := range keys {
+			index[key] = command
+		}
+	}
+	return index
+}
+
+// commandFor returns the command bound to a key, or "" if there is none
+func commandFor(key string) string {
+	return commandKeys[key]
+}
+
+// parseDirection converts a movement key into its direction
+func parseDirection(input string) (Direction, bool) {
+	dir, ok := moveDirections[commandFor(input)]
+	return dir, ok
+}
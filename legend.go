@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// legendTiles lists the terrain shown in the legend
+var legendTiles = []TileType{Floor, Wall, Door, Treasure, Trap, StairsDown, Teleporter, Chest}
+
+// legendItems lists one example of every kind of item shown in the legend,
+// built by the item constructors so their symbols always match
+func legendItems() []struct {
+	kind string
+	item Item
+} {
+	return []struct {
+		kind string
+		item Item
+	}{
+		{"Potion", NewHealthPotion(0, 0)},
+		{"Spellbook", NewSpellbook(0, 0, SpellHeal)},
+		{"Food", NewFood(0, 0)},
+		{"Torch", NewTorch(0, 0)},
+		{"Weapon", NewWeapon(0, 0, weaponTypes[0].name, weaponTypes[0].value, Common)},
+		{"Armor", NewArmor(0, 0, armorTypes[0].name, armorTypes[0].value, armorTypes[0].slot, Common)},
+		{"Ring", NewRing(0, 0, ringTypes[0])},
+		{"Pendant", NewPendant(0, 0, pendantTypes[0])},
+		{"Scroll", NewScroll(0, 0, ScrollMagicMapping)},
+		{"Wand", NewWand(0, 0, WandLightning, 0)},
+		{"Bomb", NewBomb(0, 0)},
+		{"Key", NewKey(0, 0)},
+		{"The Amulet of Yendor", NewAmulet(0, 0)},
+	}
+}
+
+// LegendLines describes every symbol that can appear on the current level,
+// using its theme's walls and floors and listing the enemies that live there
+func (d *Dungeon) LegendLines() []string {
+	lines := []string{"@ - You"}
+	for _, tile := range legendTiles {
+		symbol := rune(tile)
+		switch tile {
+		case Floor:
+			symbol = d.Theme.FloorSymbol
+		case Wall:
+			symbol = d.Theme.WallSymbol
+		}
+		lines = append(lines, fmt.Sprintf("%c - %s", symbol, tile.Name()))
+	}
+	lines = append(lines, fmt.Sprintf("%c - Fire", fireSymbol))
+	for _, entry := range legendItems() {
+		lines = append(lines, fmt.Sprintf("%c - %s", entry.item.Symbol, entry.kind))
+	}
+
+	// Each kind of enemy, in the theme's order
+	for _, entry := range d.Theme.Enemies {
+		lines = append(lines, fmt.Sprintf("%c - %s", enemyTypes[entry.Name].symbol, entry.Name))
+	}
+	return lines
+}
+
+// PrintLegend writes the legend for the current level
+func (d *Dungeon) PrintLegend(w io.Writer) {
+	fmt.Fprintf(w, "\n=== Legend: %s ===\n", d.Theme.Name)
+	for _, line := range d.LegendLines() {
+		fmt.Fprintln(w, "  "+line)
+	}
+	fmt.Fprintln(w)
+}
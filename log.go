@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// MessageLog keeps the most recent game messages in a fixed-size ring buffer
+type MessageLog struct {
+	messages []string // Ring buffer storage
+	start    int      // Index of the oldest message
+	count    int      // Number of messages stored
+	unread   int      // Number of messages not yet shown to the player
+	total    int      // Number of messages ever added
+}
+
+// NewMessageLog creates a message log holding up to capacity messages
+func NewMessageLog(capacity int) *MessageLog {
+	return &MessageLog{messages: make([]string, capacity)}
+}
+
+// Log formats a message and adds it to the log. A nil log discards it, so a
+// player or level set up without a game can still act.
+func (l *MessageLog) Log(format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	l.Add(fmt.Sprintf(format, args...))
+}
+
+// Add appends a message, overwriting the oldest one when the log is full
+func (l *MessageLog) Add(msg string) {
+	capacity := len(l.messages)
+	if l.count < capacity {
+		l.messages[(l.start+l.count)%capacity] = msg
+		l.count++
+	} else {
+		l.messages[l.start] = msg
+		l.start = (l.start + 1) % capacity
+	}
+	
+	if l.unread < l.count {
+		l.unread++
+	}
+	l.total++
+}
+
+// Mark returns a marker for the current end of the log, for use with Since
+func (l *MessageLog) Mark() int {
+	return l.total
+}
+
+// Since returns the messages added after the given marker that are still in the log
+func (l *MessageLog) Since(mark int) []string {
+	return l.Last(l.total - mark)
+}
+
+// Last returns up to n of the most recent messages, oldest first
+func (l *MessageLog) Last(n int) []string {
+	if n > l.count {
+		n = l.count
+	}
+	
+	result := make([]string, 0, n)
+	for i := l.count - n; i < l.count; i++ {
+		result = append(result, l.messages[(l.start+i)%len(l.messages)])
+	}
+	return result
+}
+
+// PrintRecent prints the last n messages and marks everything as read
+func (l *MessageLog) PrintRecent(w io.Writer, n int) {
+	for _, msg := range l.Last(n) {
+		fmt.Fprintln(w, msg)
+	}
+	l.MarkRead()
+}
+
+// MarkRead marks every message as seen by the player
+func (l *MessageLog) MarkRead() {
+	l.unread = 0
+}
+
+// PrintUnread prints the messages the player hasn't seen yet
+func (l *MessageLog) PrintUnread(w io.Writer) {
+	l.PrintRecent(w, l.unread)
+}
+
+// PrintPage prints one page of the message history, where page 1 holds the most recent messages
+func (l *MessageLog) PrintPage(w io.Writer, page, pageSize int) {
+	end := l.count - (page-1)*pageSize
+	if page < 1 || end <= 0 {
+		fmt.Fprintln(w, "No older messages.")
+		return
+	}
+	begin := end - pageSize
+	if begin < 0 {
+		begin = 0
+	}
+	
+	fmt.Fprintf(w, "=== Messages (page %d) ===\n", page)
+	for i := begin; i < end; i++ {
+		fmt.Fprintln(w, l.messages[(l.start+i)%len(l.messages)])
+	}
+}
@@ -1,254 +1,302 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
-	"math/rand"
+	"io"
 	"os"
-	"strings"
+	"strconv"
 	"time"
 )
 
-// Game states
-const (
-	StateMainMenu = iota
-	StatePlaying
-	StateInventory
-	StateGameOver
-)
-
 func main() {
-	// Seed the random number generator
-	rand.Seed(time.Now().UnixNano())
+	tui := flag.Bool("tui", false, "use the full-screen terminal interface")
+	keys := flag.Bool("keys", false, "read single keypresses without waiting for Enter")
+	debug := flag.Bool("debug", false, "allow debugging commands such as reveal")
+	seed := flag.String("seed", "", "replay the run generated from this seed, a number or any text")
+	daily := flag.Bool("daily", false, "play today's daily challenge, the same dungeon for everyone")
+	record := flag.String("record", "", "save every command to this file so the run can be replayed")
+	replay := flag.String("replay", "", "play back a run saved with -record")
+	mapFile := flag.String("map", "", "start on a level loaded from this text map instead of a generated one")
+	sims := flag.Int("sim", 0, "play this many games with a bot and print balance statistics")
+	className := flag.String("class", "", "play as this class (warrior, mage, or rogue) instead of choosing at the start")
+	name := flag.String("name", "", "play under this name instead of being asked for one")
+	flag.Parse()
+	
+	// Use the player's own key layout, if they have one; the
+	// simulation bot always plays with the default keys
+	if *sims == 0 {
+		if err := LoadKeyBindings("keys.json"); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not load keys.json:", err)
+			os.Exit(1)
+		}
+	}
+	
+	// Load the game balance, if the player has tweaked it
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load "+configFile+":", err)
+		os.Exit(1)
+	}
+	
+	// Let the bot play a batch of games for balance testing
+	if *sims > 0 {
+		start := time.Now().UnixNano()
+		if *seed != "" {
+			start = parseSeed(*seed)
+		}
+		Simulate(*sims, start, cfg).Print(os.Stdout)
+		return
+	}
 	
-	// Initialize game state
-	gameState := StatePlaying
+	// Play back a recorded run on the same seed, then stop
+	if *replay != "" {
+		if err := replayFile(*replay, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not replay the run:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	
+	// The full-screen interface draws the game itself
+	var in *Input
+	var out io.Writer
+	if !*tui {
+		in, out = NewInput(os.Stdin, os.Stdout, *keys), os.Stdout
+	}
 	
-	// Create a new dungeon
-	dungeon := NewDungeon(80, 24)
+	// Start from the day's seed for the daily challenge, the chosen seed, or a random one
+	var game *Game
+	switch {
+	case *daily:
+		date := today()
+		game = NewGameWithSeed(in, out, cfg, dailySeed(date))
+		game.Daily = date
+	case *seed != "":
+		game = NewGameWithSeed(in, out, cfg, parseSeed(*seed))
+		if _, err := strconv.ParseInt(*seed, 10, 64); err != nil {
+			game.SeedText = *seed
+		}
+	default:
+		game = NewGame(in, out, cfg)
+	}
+	game.Debug = *debug
+	game.SettingsFile = configFile
+	
+	// Take the name from the command line, or ask for it when playing line by line
+	switch {
+	case *name != "":
+		game.SetName(*name)
+	case in != nil:
+		game.SetName(game.promptName())
+	}
 	
-	// Create a new player in the first room
-	var player *Player
+	// Take the class from the command line, or ask for it when playing line by line
+	switch {
+	case *className != "":
+		class, ok := parseClass(*className)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown class %q: choose warrior, mage, or rogue\n", *className)
+			os.Exit(1)
+		}
+		game.ChooseClass(class)
+	case in != nil:
+		game.ChooseClass(game.promptClass())
+	}
+	
+	// Swap in a hand-made first level
+	if *mapFile != "" {
+		if err := game.StartOnMap(*mapFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not load %s: %v\n", *mapFile, err)
+			os.Exit(1)
+		}
+	}
+	
+	// Interactive games open on the main menu
+	game.State = StateMainMenu
+	
+	// Keep a copy of every command to replay later
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not start recording:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		game.StartRecording(f)
+	}
+	
+	// The full-screen interface runs its own loop
+	if *tui {
+		if err := runTUI(game); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not start the terminal interface:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	game.Run()
+}
+
+// replayFile plays back the run recorded in the file at path
+func replayFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	start, commands, err := readRecording(f)
+	if err != nil {
+		return err
+	}
+	game := NewGameWithSeed(nil, os.Stdout, cfg, start.Seed)
+	if err := start.Apply(game); err != nil {
+		return err
+	}
+	game.Replay(commands)
+	return nil
+}
+
+// parseSeed reads a numeric seed, or hashes any other text into one
+func parseSeed(text string) int64 {
+	if seed, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return seed
+	}
+	return seedFromString(text)
+}
+
+// printHelp displays the game instructions
+func printHelp(w io.Writer) {
+	fmt.Fprintln(w, "\n=== Instructions ===")
+	fmt.Fprintln(w, "Movement: w/up, a/left, s/down, d/right (or vi-style k, h, j, l)")
+	fmt.Fprintln(w, "Actions:")
+	fmt.Fprintln(w, "  i - Open inventory")
+	fmt.Fprintln(w, "  H - Quick heal: drink your best health potion")
+	fmt.Fprintln(w, "  g - Pick up what's here")
+	fmt.Fprintln(w, "  autopickup - Toggle picking things up by stepping on them")
+	fmt.Fprintln(w, "  c - Cast a spell")
+	fmt.Fprintln(w, "  z - Zap a wand")
+	fmt.Fprintln(w, "  x - Look at an adjacent tile")
+	fmt.Fprintln(w, "  f - Search adjacent tiles for hidden traps and secret doors")
+	fmt.Fprintln(w, "  D - Disarm an adjacent trap you have spotted")
+	fmt.Fprintln(w, "  F - Flee: step away from the nearest enemy without attacking")
+	fmt.Fprintln(w, "  n - Sneak: enemies notice you from half as far away, but you move at half speed")
+	fmt.Fprintln(w, "  T - Travel to the stairs down once you have found them ('travel >')")
+	fmt.Fprintln(w, "  M - Main menu (start a new game or quit; choose Continue to return)")
+	fmt.Fprintln(w, "  > - Descend stairs (when standing on them)")
+	fmt.Fprintln(w, "  r - Rest to recover health and mana ('rest 10' or 'rest full' to keep resting)")
+	fmt.Fprintln(w, "  . - Wait a turn")
+	fmt.Fprintln(w, "  Enter - Repeat your last action ('repeat 5 d' does it up to 5 times, stopping if anything happens)")
+	fmt.Fprintln(w, "  m - Show recent messages ('m 2' for older ones)")
+	fmt.Fprintln(w, "  ? - Show this help")
+	fmt.Fprintln(w, "  L - Show what the symbols on this level mean")
+	fmt.Fprintln(w, "  export - Save the map of this level to a text file ('export mymap.txt' to choose the name)")
+	fmt.Fprintln(w, "  q - Quit game")
+	fmt.Fprintln(w, "Commands can also be typed out, e.g. 'go north', 'use potion', 'drop dagger', or 'throw dagger d'.")
+	fmt.Fprintln(w, "\nSymbols:")
+	fmt.Fprintln(w, "  @ - Player")
+	fmt.Fprintln(w, "  . - Floor")
+	fmt.Fprintln(w, "  # - Wall")
+	fmt.Fprintln(w, "  + - Door")
+	fmt.Fprintln(w, "  $ - Treasure")
+	fmt.Fprintln(w, "  ^ - Trap (hidden until you spot it)")
+	fmt.Fprintln(w, "  } - Fire (burns whoever stands in it, and spreads)")
+	fmt.Fprintln(w, "  > - Stairs down")
+	fmt.Fprintln(w, "  0 - Teleporter (leads to its partner elsewhere on the level)")
+	fmt.Fprintln(w, "  C - Locked chest (walk into it with a key to open it)")
+	fmt.Fprintln(w, "  k - Key")
+	fmt.Fprintln(w, "  ! - Potion (health, fire, or the rare strength)")
+	fmt.Fprintln(w, "  ? - Spellbook")
+	fmt.Fprintln(w, "  % - Food")
+	fmt.Fprintln(w, "  ~ - Torch")
+	fmt.Fprintln(w, "  / - Weapon")
+	fmt.Fprintln(w, "  [ - Armor")
+	fmt.Fprintln(w, "  ( - Ring")
+	fmt.Fprintln(w, "  * - Pendant")
+	fmt.Fprintln(w, "  & - Scroll")
+	fmt.Fprintln(w, "  - - Wand")
+	fmt.Fprintln(w, "  ` - Bomb (throw it to blast everything around where it lands)")
+	fmt.Fprintln(w, "  \" - The Amulet of Yendor")
+	fmt.Fprintln(w, "  g/o/T/s/r/m/t/G/b - Enemies (goblin, orc, troll, skeleton, rat, mold, thief, ghost, bat)")
+	fmt.Fprintln(w, "  d - A friendly dog")
+	fmt.Fprintln(w, "\nCombat: Move into enemies to attack them")
+	fmt.Fprintln(w)
+}
+
+// placeInFirstRoom puts the player in the center of the level's first room
+func placeInFirstRoom(player *Player, dungeon *Dungeon) {
 	if len(dungeon.Rooms) > 0 {
-		// Place player in the center of the first room
 		room := dungeon.Rooms[0]
-		player = NewPlayer(room.X+room.Width/2, room.Y+room.Height/2)
+		player.X = room.X + room.Width/2
+		player.Y = room.Y + room.Height/2
 	} else {
-		// Fallback if no rooms were generated
-		player = NewPlayer(1, 1)
+		player.X, player.Y = 1, 1
 	}
+}
 
-	// Create a reader for user input
-	reader := bufio.NewReader(os.Stdin)
-
-	// Display welcome message and instructions
-	fmt.Println("=== Welcome to Dungeon Crawler ===")
-	printHelp()
-
-	// Main game loop
-	for {
-		// Handle different game states
-		switch gameState {
-		case StatePlaying:
-			// Display the dungeon and player status
-			dungeon.Print(player)
-			player.DisplayStatus()
-			
-			// Process player input
-			fmt.Print("\nEnter command: ")
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
-			
-			// Process the command
-			switch input {
-			case "q", "quit":
-				fmt.Println("Thanks for playing! Goodbye!")
-				return
-				
-			case "w", "up":
-				player.Move(0, -1, dungeon)
-				dungeon.MoveEnemies(player) // Enemies move after player
-				
-			case "s", "down":
-				player.Move(0, 1, dungeon)
-				dungeon.MoveEnemies(player)
-				
-			case "a", "left":
-				player.Move(-1, 0, dungeon)
-				dungeon.MoveEnemies(player)
-				
-			case "d", "right":
-				player.Move(1, 0, dungeon)
-				dungeon.MoveEnemies(player)
-				
-			case "i", "inventory":
-				gameState = StateInventory
-				
-			case ">":
-				// Check if player is on stairs
-				if dungeon.GetTileAt(player.X, player.Y) == StairsDown {
-					// Generate a new dungeon level
-					dungeon = NewDungeon(80, 24)
-					dungeon.Level = dungeon.Level + 1
-					
-					// Place player in the first room of the new level
-					if len(dungeon.Rooms) > 0 {
-						room := dungeon.Rooms[0]
-						player.X = room.X + room.Width/2
-						player.Y = room.Y + room.Height/2
-					} else {
-						player.X, player.Y = 1, 1
-					}
-					
-					fmt.Printf("You descend to dungeon level %d...\n", dungeon.Level)
-				} else {
-					fmt.Println("There are no stairs here.")
-				}
-				
-			case "h", "help":
-				printHelp()
-				
-			case "r", "rest":
-				// Rest to recover health (with risk)
-				if rand.Intn(3) == 0 {
-					// 1/3 chance of enemy encounter during rest
-					fmt.Println("Your rest is interrupted by a wandering monster!")
-					// Spawn a random enemy near the player
-					spawnEnemyNearPlayer(player, dungeon)
-				} else {
-					// Recover some health
-					healAmount := 2 + rand.Intn(3)
-					player.Health += healAmount
-					if player.Health > player.MaxHealth {
-						player.Health = player.MaxHealth
-					}
-					fmt.Printf("You rest and recover %d health points.\n", healAmount)
-					dungeon.MoveEnemies(player) // Enemies still move while resting
-				}
-				
-			default:
-				fmt.Println("Unknown command. Type 'h' or 'help' for instructions.")
-			}
-			
-			// Check if player is dead
-			if player.Health <= 0 {
-				gameState = StateGameOver
-			}
-			
-		case StateInventory:
-			// Display inventory
-			fmt.Println("\n=== Inventory ===")
-			player.DisplayInventory()
-			fmt.Println("\nEnter item number to use it, or 'b' to go back:")
-			
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
-			
-			if input == "b" || input == "back" {
-				gameState = StatePlaying
-			} else {
-				// Try to parse item index
-				var itemIndex int
-				_, err := fmt.Sscanf(input, "%d", &itemIndex)
-				if err == nil && itemIndex > 0 && itemIndex <= len(player.Inventory) {
-					player.UseItem(itemIndex - 1) // Convert to 0-based index
-				} else {
-					fmt.Println("Invalid item selection.")
-				}
-			}
-			
-		case StateGameOver:
-			// Game over screen
-			fmt.Println("\n=== GAME OVER ===")
-			fmt.Printf("You died on dungeon level %d.\n", dungeon.Level)
-			fmt.Printf("Final score: %d gold collected.\n", player.Gold)
-			fmt.Println("\nPress 'r' to restart or 'q' to quit:")
-			
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
-			
-			if input == "r" || input == "restart" {
-				// Restart the game
-				dungeon = NewDungeon(80, 24)
-				if len(dungeon.Rooms) > 0 {
-					room := dungeon.Rooms[0]
-					player = NewPlayer(room.X+room.Width/2, room.Y+room.Height/2)
-				} else {
-					player = NewPlayer(1, 1)
-				}
-				gameState = StatePlaying
-			} else if input == "q" || input == "quit" {
-				fmt.Println("Thanks for playing! Goodbye!")
-				return
-			}
-		}
+// rest lets the player recover health and mana, at the risk of attracting a monster.
+// It returns true if the rest took a turn.
+func rest(player *Player, dungeon *Dungeon) bool {
+	cfg := dungeon.Config
+	if dungeon.Rng.Intn(cfg.RestInterruptOdds) == 0 {
+		// Chance of enemy encounter during rest
+		player.Messages.Log("Your rest is interrupted by a wandering monster!")
+		// Spawn a random enemy near the player
+		spawnEnemyNearPlayer(player, dungeon)
+		return false
+	}
+	
+	// Recover some health
+	healAmount := cfg.RestHealMin + dungeon.Rng.Intn(cfg.RestHealMax-cfg.RestHealMin+1)
+	player.Health += healAmount
+	if player.Health > player.MaxHealth {
+		player.Health = player.MaxHealth
+	}
+	player.Messages.Log("You rest and recover %d health points.", healAmount)
+	
+	// Resting makes you hungrier
+	player.Hunger -= 5
+	if player.Hunger < 0 {
+		player.Hunger = 0
+	}
+	
+	// Mana slowly returns while resting
+	if player.Mana < player.MaxMana {
+		player.Mana++
+		player.Messages.Log("You recover 1 mana point.")
 	}
+	return true
 }
 
-// printHelp displays the game instructions
-func printHelp() {
-	fmt.Println("\n=== Instructions ===")
-	fmt.Println("Movement: w/up, a/left, s/down, d/right")
-	fmt.Println("Actions:")
-	fmt.Println("  i - Open inventory")
-	fmt.Println("  > - Descend stairs (when standing on them)")
-	fmt.Println("  r - Rest to recover health")
-	fmt.Println("  h - Show this help")
-	fmt.Println("  q - Quit game")
-	fmt.Println("\nSymbols:")
-	fmt.Println("  @ - Player")
-	fmt.Println("  . - Floor")
-	fmt.Println("  # - Wall")
-	fmt.Println("  + - Door")
-	fmt.Println("  $ - Treasure")
-	fmt.Println("  ^ - Trap")
-	fmt.Println("  > - Stairs down")
-	fmt.Println("  g/o/T/s - Enemies (goblin, orc, troll, skeleton)")
-	fmt.Println("\nCombat: Move into enemies to attack them")
-	fmt.Println()
+// newPlayerIn creates a new player in the center of the dungeon's first room,
+// logging to the same messages as the dungeon
+func newPlayerIn(dungeon *Dungeon, class Class) *Player {
+	x, y := 1, 1 // Fallback if no rooms were generated
+	if len(dungeon.Rooms) > 0 {
+		room := dungeon.Rooms[0]
+		x, y = room.X+room.Width/2, room.Y+room.Height/2
+	}
+	p := NewPlayer(x, y, dungeon.Config, class)
+	p.Messages = dungeon.Messages
+	return p
 }
 
 // spawnEnemyNearPlayer creates a random enemy near the player
 func spawnEnemyNearPlayer(player *Player, dungeon *Dungeon) {
-	// Define possible spawn positions (adjacent to player)
-	positions := []struct{ dx, dy int }{
-		{-1, -1}, {0, -1}, {1, -1},
-		{-1, 0},           {1, 0},
-		{-1, 1},  {0, 1},  {1, 1},
-	}
-	
-	// Try each position
-	for _, pos := range positions {
-		x, y := player.X+pos.dx, player.Y+pos.dy
+	// Try each position adjacent to the player
+	for _, dir := range allDirections {
+		dx, dy := dir.Delta()
+		x, y := player.X+dx, player.Y+dy
 		
 		// Check if position is valid
 		if dungeon.IsWalkable(x, y) && dungeon.GetEnemyAt(x, y) == nil {
 			// Create a random enemy
-			enemyTypes := []struct {
-				name   string
-				symbol rune
-				health int
-				damage int
-			}{
-				{"Goblin", 'g', 3, 1},
-				{"Rat", 'r', 1, 1},
-			}
-			
-			enemyType := enemyTypes[rand.Intn(len(enemyTypes))]
+			wanderers := []string{"Goblin", "Rat"}
+			enemyType := enemyTypes[wanderers[dungeon.Rng.Intn(len(wanderers))]]
 			
 			// Create and add the enemy
-			enemy := &Enemy{
-				X:       x,
-				Y:       y,
-				Health:  enemyType.health,
-				Symbol:  enemyType.symbol,
-				Name:    enemyType.name,
-				Damage:  enemyType.damage,
-				Hostile: true,
-			}
-			
-			dungeon.Enemies = append(dungeon.Enemies, enemy)
-			fmt.Printf("A %s appears!\n", enemy.Name)
+			enemy := enemyType.spawn(x, y)
+			dungeon.AddEnemy(enemy)
+			dungeon.Messages.Log("A %s appears!", enemy.Name)
 			return
 		}
 	}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// mapTerrain lists the terrain symbols a map file may use. A space, as left
+// by unexplored parts of an exported map, counts as wall.
+var mapTerrain = map[rune]bool{
+	rune(Floor): true, rune(Wall): true, rune(Door): true, rune(Treasure): true,
+	rune(Trap): true, rune(StairsDown): true, rune(Teleporter): true, rune(Chest): true,
+}
+
+// mapItems gives the item each item symbol in a map file stands for. Symbols
+// shared by several kinds of item load as the plainest of them.
+var mapItems = map[rune]func(x, y int) Item{
+	'!': NewHealthPotion,
+	'%': NewFood,
+	'~': NewTorch,
+	'k': NewKey,
+	'"': NewAmulet,
+	'`': NewBomb,
+	'?': func(x, y int) Item { return NewSpellbook(x, y, SpellMagicMissile) },
+	'&': func(x, y int) Item { return NewScroll(x, y, ScrollMagicMapping) },
+	'-': func(x, y int) Item { return NewWand(x, y, WandLightning, 3) },
+	'(': func(x, y int) Item { return NewRing(x, y, ringTypes[0]) },
+	'*': func(x, y int) Item { return NewPendant(x, y, pendantTypes[0]) },
+	'/': func(x, y int) Item {
+		kind := weaponTypes[0]
+		return NewWeapon(x, y, kind.name, kind.value, Common)
+	},
+	'[': func(x, y int) Item {
+		kind := armorTypes[0]
+		return NewArmor(x, y, kind.name, kind.value, kind.slot, Common)
+	},
+}
+
+// LoadDungeonFromFile reads a level from a text map in the format written by
+// ExportMap: one symbol per tile and one line per row. The map must be
+// rectangular and mark the player's start with '@' and the stairs with '>'.
+// Enemies are placed from their symbols, rooms are worked out from the
+// areas of floor, and the level plays as depth 1.
+func LoadDungeonFromFile(path string) (*Dungeon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDungeon(string(data))
+}
+
+// parseDungeon builds a level from the text of a map file
+func parseDungeon(text string) (*Dungeon, error) {
+	var rows [][]rune
+	for _, line := range strings.Split(strings.TrimRight(text, "\r\n"), "\n") {
+		rows = append(rows, []rune(strings.TrimSuffix(line, "\r")))
+	}
+	if len(rows[0]) == 0 {
+		return nil, fmt.Errorf("the map is empty")
+	}
+	width, height := len(rows[0]), len(rows)
+	for i, row := range rows {
+		if len(row) != width {
+			return nil, fmt.Errorf("row %d is %d tiles wide but row 1 is %d; the map must be rectangular", i+1, len(row), width)
+		}
+	}
+
+	enemies := map[rune]enemyType{strayDog.symbol: strayDog}
+	for _, kind := range enemyTypes {
+		enemies[kind.symbol] = kind
+	}
+
+	d := newSolidDungeon(width, height, 1, rand.New(rand.NewSource(0)), DefaultConfig())
+	hasStart, hasStairs := false, false
+	var teleporters [][2]int
+	for y, row := range rows {
+		for x, r := range row {
+			d.set(x, y, rune(Floor)) // Whatever stands here is on floor
+			kind, isEnemy := enemies[r]
+			newItem, isItem := mapItems[r]
+			switch {
+			case r == '@' && hasStart:
+				return nil, fmt.Errorf("the map has more than one player start ('@')")
+			case r == '@':
+				d.Start, hasStart = [2]int{x, y}, true
+			case r == ' ':
+				d.set(x, y, rune(Wall))
+			case r == fireSymbol:
+				// Flames in an exported map have burnt out by the time it's loaded
+			case mapTerrain[r]:
+				d.set(x, y, r)
+			case isEnemy:
+				enemy := kind.spawn(x, y)
+				enemy.Hostile = kind.symbol != strayDog.symbol
+				d.AddEnemy(enemy)
+			case isItem:
+				d.AddItem(newItem(x, y))
+			default:
+				return nil, fmt.Errorf("unknown symbol %q at (%d,%d)", r, x, y)
+			}
+
+			switch TileType(d.at(x, y)) {
+			case StairsDown:
+				hasStairs = true
+			case Trap:
+				d.Traps[[2]int{x, y}] = &TrapState{Kind: TrapSpikes, Under: rune(Floor)}
+			case Teleporter:
+				teleporters = append(teleporters, [2]int{x, y})
+			}
+		}
+	}
+	if !hasStart {
+		return nil, fmt.Errorf("the map has no player start ('@')")
+	}
+	if !hasStairs {
+		return nil, fmt.Errorf("the map has no stairs down ('>')")
+	}
+
+	// Teleporters link up in pairs, in the order they appear
+	if len(teleporters)%2 != 0 {
+		return nil, fmt.Errorf("the map has %d teleporters, but they must come in pairs", len(teleporters))
+	}
+	for i := 0; i < len(teleporters); i += 2 {
+		a, b := teleporters[i], teleporters[i+1]
+		d.Teleporters[a], d.Teleporters[b] = b, a
+	}
+
+	d.findRooms()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if d.at(x, y) == rune(Chest) {
+				d.Chests[[2]int{x, y}] = d.chestContents(x, y)
+			}
+		}
+	}
+	return d, nil
+}
+
+// findRooms works out the rooms of a loaded level by flood-filling each area
+// of connected floor, stopping at walls and doors, and taking the rectangle
+// around it. Areas only one tile thick are corridors, not rooms.
+func (d *Dungeon) findRooms() {
+	seen := make([]bool, len(d.Grid))
+	for start := range d.Grid {
+		if seen[start] || !d.IsWalkable(start%d.Width, start/d.Width) || d.Grid[start] == rune(Door) {
+			continue
+		}
+
+		minX, minY := d.Width, d.Height
+		maxX, maxY := -1, -1
+		queue := []int{start}
+		seen[start] = true
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			x, y := i%d.Width, i/d.Width
+			minX, minY = min(minX, x), min(minY, y)
+			maxX, maxY = max(maxX, x), max(maxY, y)
+			for _, dir := range orthogonalDirections {
+				dx, dy := dir.Delta()
+				nx, ny := x+dx, y+dy
+				if !d.inBounds(nx, ny) {
+					continue
+				}
+				next := ny*d.Width + nx
+				if !seen[next] && d.IsWalkable(nx, ny) && d.Grid[next] != rune(Door) {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		if maxX > minX && maxY > minY {
+			d.Rooms = append(d.Rooms, Room{X: minX, Y: minY, Width: maxX - minX + 1, Height: maxY - minY + 1})
+		}
+	}
+}
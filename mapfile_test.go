@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// exportAndLoad writes d to a map file as p sees it and loads it back
+func exportAndLoad(t *testing.T, d *Dungeon, p *Player) *Dungeon {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "level.txt")
+	if err := d.ExportMap(path, p); err != nil {
+		t.Fatalf("exporting the map: %v", err)
+	}
+	loaded, err := LoadDungeonFromFile(path)
+	if err != nil {
+		data, _ := os.ReadFile(path)
+		t.Fatalf("loading the exported map: %v\n%s", err, data)
+	}
+	return loaded
+}
+
+func TestExportedLevelsLoad(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		for level := 1; level <= 5; level++ {
+			d := NewDungeon(level, rand.New(rand.NewSource(seed)), DefaultConfig())
+			d.Revealed = true
+			exportAndLoad(t, d, newPlayerIn(d, ClassWarrior))
+		}
+	}
+}
+
+func TestExportMapRoundTrip(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.Revealed = true
+	d.Items, d.itemAt = nil, make(map[[2]int]int)
+	d.clearEnemies()
+	p := newPlayerIn(d, ClassWarrior)
+
+	// Lay one of every kind of item along the floor of the first room, with a
+	// fire at the end of the row
+	room := d.Rooms[0]
+	items := []Item{
+		NewHealthPotion(0, 0), NewFood(0, 0), NewTorch(0, 0), NewKey(0, 0),
+		NewAmulet(0, 0), NewBomb(0, 0), NewSpellbook(0, 0, SpellMagicMissile),
+		NewScroll(0, 0, ScrollTeleport), NewWand(0, 0, WandFire, 2),
+		NewRing(0, 0, ringTypes[1]), NewPendant(0, 0, pendantTypes[1]),
+		NewWeapon(0, 0, weaponTypes[0].name, weaponTypes[0].value, Common),
+		NewArmor(0, 0, armorTypes[0].name, armorTypes[0].value, armorTypes[0].slot, Common),
+	}
+	var spots [][2]int
+	for y := room.Y; y < room.Y+room.Height && len(spots) <= len(items); y++ {
+		for x := room.X; x < room.X+room.Width && len(spots) <= len(items); x++ {
+			if d.at(x, y) == rune(Floor) && (x != p.X || y != p.Y) {
+				spots = append(spots, [2]int{x, y})
+			}
+		}
+	}
+	if len(spots) <= len(items) {
+		t.Fatalf("the first room has only %d free floor tiles", len(spots))
+	}
+	for i, item := range items {
+		item.X, item.Y = spots[i][0], spots[i][1]
+		d.AddItem(item)
+	}
+	fire := spots[len(items)]
+	d.Ignite(fire[0], fire[1], fireDuration)
+
+	loaded := exportAndLoad(t, d, p)
+	if loaded.Start != [2]int{p.X, p.Y} {
+		t.Errorf("start = %v, want (%d,%d)", loaded.Start, p.X, p.Y)
+	}
+	for i, item := range items {
+		got := loaded.GetItemAt(spots[i][0], spots[i][1])
+		if got == nil || got.Symbol != item.Symbol {
+			t.Errorf("%s at %v didn't load back as a %q", item.Name, spots[i], item.Symbol)
+		}
+	}
+	if r := loaded.at(fire[0], fire[1]); r != rune(Floor) {
+		t.Errorf("the burning tile loaded as %q, want floor", r)
+	}
+}
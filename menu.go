@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// menuOption is one choice on the main menu
+type menuOption struct {
+	Command string // What the game does, also accepted typed out
+	Label   string // What the menu shows
+}
+
+// menuOptions are the main menu's choices, in the order they are numbered
+var menuOptions = []menuOption{
+	{"new", "New Game"},
+	{"continue", "Continue"},
+	{"settings", "Settings"},
+	{"quit", "Quit"},
+}
+
+// MenuLines returns the main menu as numbered lines
+func (g *Game) MenuLines() []string {
+	lines := []string{"=== Main Menu ==="}
+	for i, option := range menuOptions {
+		label := option.Label
+		if option.Command == "continue" && g.inProgress() {
+			label += fmt.Sprintf(" (%s, depth %d, turn %d)", g.Player.Class, g.Dungeon.Level, g.Turns)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, label))
+	}
+	return lines
+}
+
+// menuChoice reads a main menu choice by number or name, returning "" if it
+// isn't one
+func menuChoice(input string) string {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(menuOptions) {
+		return menuOptions[n-1].Command
+	}
+	for _, option := range menuOptions {
+		if input == option.Command || input == strings.ToLower(option.Label) {
+			return option.Command
+		}
+	}
+	return ""
+}
+
+// inProgress reports whether the current run has been played at all, so
+// there is something to continue
+func (g *Game) inProgress() bool {
+	return g.Turns > 0
+}
+
+// updateMainMenu handles a choice on the main menu
+func (g *Game) updateMainMenu(cmd string) {
+	switch menuChoice(cmd) {
+	case "new":
+		// The first run is ready to go; later ones need a fresh dungeon
+		if g.inProgress() {
+			g.restart()
+		}
+		g.State = StatePlaying
+	case "continue":
+		if !g.inProgress() {
+			g.Messages.Log("There is no game to continue. Choose New Game to start one.")
+			return
+		}
+		g.State = StatePlaying
+	case "settings":
+		g.State = StateSettings
+	case "quit":
+		g.State = StateQuit
+	default:
+		g.Messages.Log("Choose an option by its number, 1 to %d.", len(menuOptions))
+	}
+}
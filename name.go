@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// defaultName is what the player is called if they don't choose a name
+const defaultName = "Adventurer"
+
+// maxNameLength is the most characters a player name may have
+const maxNameLength = 20
+
+// cleanName tidies a name the player typed: it keeps letters, digits,
+// spaces, hyphens, and apostrophes, squeezes runs of spaces, and cuts it to
+// maxNameLength characters. An empty result falls back to defaultName.
+func cleanName(text string) string {
+	kept := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '-' || r == '\'' {
+			return r
+		}
+		return -1
+	}, text)
+	name := []rune(strings.Join(strings.Fields(kept), " "))
+	if len(name) > maxNameLength {
+		name = []rune(strings.TrimSpace(string(name[:maxNameLength])))
+	}
+	if len(name) == 0 {
+		return defaultName
+	}
+	return string(name)
+}
+
+// promptName asks the player what they are called
+func (g *Game) promptName() string {
+	fmt.Fprintf(g.Out, "What is your name? (Enter for %s): ", defaultName)
+	return cleanName(g.In.ReadLine())
+}
+
+// SetName names the player, for this run and any restart
+func (g *Game) SetName(name string) {
+	g.Name = cleanName(name)
+	g.Player.Name = g.Name
+}
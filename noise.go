@@ -0,0 +1,19 @@
+package main
+
+// How far away enemies hear the noise of different actions
+const (
+	fightNoise     = 6  // Trading blows with an enemy
+	doorNoise      = 4  // Forcing a door open
+	explosionNoise = 10 // A bomb going off
+)
+
+// MakeNoise wakes every hostile enemy within radius of (x, y), walls or not.
+// Enemies that hear it become aware of the player and come looking for them.
+func (d *Dungeon) MakeNoise(x, y, radius int) {
+	for _, enemy := range d.Enemies {
+		if enemy.Health > 0 && enemy.Hostile && !enemy.Disguised && distance(x, y, enemy.X, enemy.Y) <= radius {
+			enemy.Aware = true
+		}
+	}
+	d.AlertEnemies(x, y, radius)
+}
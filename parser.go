@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// verbAliases maps extra words the player may type to the command they
+// stand for, on top of the key bindings
+var verbAliases = map[string]string{
+	"go":      "go",
+	"walk":    "go",
+	"move":    "go",
+	"use":     "use",
+	"drink":   "use",
+	"eat":     "use",
+	"read":    "use",
+	"wear":    "use",
+	"wield":   "use",
+	"equip":   "use",
+	"drop":    "drop",
+	"throw":   "throw",
+	"examine": "look",
+	"inv":     "inventory",
+	"heal":    "quickheal",
+	"stairs":  "descend",
+	"exit":    "quit",
+	"repeat":  "repeat",
+}
+
+// directionWords maps spelled-out directions to the movement command for them
+var directionWords = map[string]string{
+	"north": "move_up",
+	"south": "move_down",
+	"east":  "move_right",
+	"west":  "move_left",
+}
+
+// stepCommand returns the command to move one tile in dir, which must be
+// one of the four orthogonal directions
+func stepCommand(dir Direction) string {
+	return "go " + map[Direction]string{North: "north", South: "south", East: "east", West: "west"}[dir]
+}
+
+// parseCommand splits a line of input into the command it names and the
+// command's arguments. Bound keys are matched exactly, so "H" and "h" can do
+// different things; longer words are also tried in lower case, along with
+// aliases such as "use potion" or "go north". The command is "" if the verb
+// isn't recognised.
+func parseCommand(input string) (command string, args []string) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	verb, args := fields[0], fields[1:]
+	if command := commandFor(verb); command != "" {
+		return command, args
+	}
+
+	word := strings.ToLower(verb)
+	if command := commandFor(word); command != "" && len(word) > 1 {
+		return command, args
+	}
+	if command, ok := directionWords[word]; ok {
+		return command, args
+	}
+	command, ok := verbAliases[word]
+	if !ok {
+		return "", args
+	}
+
+	// "go" takes the direction as its argument
+	if command == "go" && len(args) > 0 {
+		dir := strings.ToLower(args[0])
+		if move, ok := directionWords[dir]; ok {
+			return move, args[1:]
+		}
+		if _, ok := parseDirection(dir); ok {
+			return commandFor(dir), args[1:]
+		}
+	}
+	return command, args
+}
+
+// commandWords lists every word parseCommand understands that is long enough
+// to be worth suggesting, leaving out debugging commands
+func commandWords() []string {
+	words := []string{}
+	for key, command := range commandKeys {
+		if len(key) > 1 && command != "reveal" {
+			words = append(words, key)
+		}
+	}
+	for word := range verbAliases {
+		words = append(words, word)
+	}
+	for word := range directionWords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// unknownCommand explains that a verb wasn't understood, suggesting the
+// closest known word if the player seems to have made a typo
+func unknownCommand(verb string) string {
+	word := strings.ToLower(verb)
+	best, bestDist := "", 3 // Only suggest words within two edits
+	if len(word) > 2 {
+		for _, candidate := range commandWords() {
+			if dist := levenshtein(word, candidate); dist < bestDist {
+				best, bestDist = candidate, dist
+			}
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("Unknown command '%s'. Did you mean '%s'?", verb, best)
+	}
+	return "Unknown command. Type '?' or 'help' for instructions."
+}
+
+// levenshtein returns the number of single-character insertions, deletions,
+// and substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,77 @@
+package main
+
+import "container/heap"
+
+// pathNode is a tile waiting to be explored by FindPath
+type pathNode struct {
+	pos  [2]int
+	cost int // Steps taken to reach the tile
+	rank int // Steps taken plus the estimate of steps left
+}
+
+// pathQueue orders tiles by rank, lowest first
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].rank < q[j].rank }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// FindPath returns the shortest walkable route from (fromX, fromY) to
+// (toX, toY) using A*, as the tiles to step on in order, excluding the start.
+// Enemies don't block the route, since they move around. It returns nil if
+// the destination can't be reached.
+func (d *Dungeon) FindPath(fromX, fromY, toX, toY int) [][2]int {
+	return d.FindPathAvoiding(fromX, fromY, toX, toY, nil)
+}
+
+// FindPathAvoiding works like FindPath, but never routes through a tile for
+// which avoid returns true, other than the destination itself. A nil avoid
+// avoids nothing.
+func (d *Dungeon) FindPathAvoiding(fromX, fromY, toX, toY int, avoid func(x, y int) bool) [][2]int {
+	start, goal := [2]int{fromX, fromY}, [2]int{toX, toY}
+	if start == goal || !d.IsWalkable(toX, toY) {
+		return nil
+	}
+
+	cameFrom := map[[2]int][2]int{}
+	cost := map[[2]int]int{start: 0}
+	queue := &pathQueue{{pos: start, rank: distance(fromX, fromY, toX, toY)}}
+	for queue.Len() > 0 {
+		node := heap.Pop(queue).(pathNode)
+		if node.pos == goal {
+			break
+		}
+		if node.cost > cost[node.pos] {
+			continue // A shorter way here was already explored
+		}
+		for _, dir := range [][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			next := [2]int{node.pos[0] + dir[0], node.pos[1] + dir[1]}
+			if !d.IsWalkable(next[0], next[1]) || (avoid != nil && next != goal && avoid(next[0], next[1])) {
+				continue
+			}
+			if known, ok := cost[next]; ok && known <= node.cost+1 {
+				continue
+			}
+			cost[next] = node.cost + 1
+			cameFrom[next] = node.pos
+			heap.Push(queue, pathNode{next, node.cost + 1, node.cost + 1 + distance(next[0], next[1], toX, toY)})
+		}
+	}
+	if _, ok := cameFrom[goal]; !ok {
+		return nil
+	}
+
+	// Walk back from the goal to build the route
+	path := make([][2]int, cost[goal])
+	for pos, i := goal, cost[goal]-1; i >= 0; pos, i = cameFrom[pos], i-1 {
+		path[i] = pos
+	}
+	return path
+}
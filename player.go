@@ -2,7 +2,8 @@ package main
 
 import (
 	"fmt"
-	"math/rand"
+	"io"
+	"strings"
 )
 
 // Player represents the player character in the game
@@ -11,34 +12,101 @@ type Player struct {
 	Health    int     // Current health points
 	MaxHealth int     // Maximum health points
 	Attack    int     // Attack damage
-	Defense   int     // Damage reduction
+	Defense   int     // Damage reduction, from BaseDefense plus worn armor
+	BaseDefense int   // Damage reduction without any armor
+	Weapon    *Item   // Weapon being wielded, if any
+	Armor     map[ArmorSlot]Item // Armor worn in each slot
+	Accessories map[AccessorySlot]Item // Rings and pendant worn in each slot
+	RegenBonus  int   // How much faster health regenerates, from accessories
+	SetRegen    int   // How much faster health regenerates, from armor sets
+	CritChance  int   // Percentage chance of a melee hit doing double damage
+	Strength    int   // Attack gained for good from potions of strength
+	Evasion     int   // Percentage chance of dodging an enemy's attack
+	Class       Class // The class the player started the run as
+	Name        string // What the player is called in messages and scores
 	Gold      int     // Gold collected
 	Level     int     // Player level
 	Exp       int     // Experience points
+	Mana      int     // Current mana points
+	MaxMana   int     // Maximum mana points
+	Spells    []SpellType // Spells the player has learned
+	Hunger    int     // Turns of food left before starving
+	LightRadius int   // How far the player can see
+	TorchTurns  int   // Turns left on the lit torch, if any
+	Perception  int   // Radius within which the player can spot traps
+	MaxCarry    int   // Maximum total weight the player can carry
+	TurnsSinceDamage int // Turns since the player last took damage
+	Stats     Stats   // Record of the run for the end-game screens
+	AttackType  DamageType // Element of the player's melee attacks, from the equipped weapon
+	Resistances map[DamageType]int // Percentage of each damage type ignored; negative for weaknesses
+	AutoPickup  bool  // Whether items and treasure are picked up just by stepping on them
+	Config      *Config // Game balance settings
+	Identities  *Identities // Which potions and scrolls the player has identified
+	Messages    *MessageLog // Where what the player does is logged
+	PoisonTurns int   // Turns of poison left, each costing 1 health
+	Falling     bool  // Set by a pit trap; the game then drops the player to the next level
+	Descending  bool  // Set by a Scroll of Descent; the game then takes the player down a level
+	Sneaking    bool  // Sneaking players are noticed from half as far away but move at half speed, unless they are rogues
 	Inventory []Item  // Items carried by the player
 }
 
+// MaxHunger is how full the player can be
+const MaxHunger = 300
+
 // NewPlayer creates a new player at the specified position
-func NewPlayer(x, y int) *Player {
-	return &Player{
+func NewPlayer(x, y int, cfg *Config, class Class) *Player {
+	p := &Player{
 		X:         x,
 		Y:         y,
-		Health:    20,
-		MaxHealth: 20,
-		Attack:    3,
-		Defense:   1,
+		Health:    cfg.startHealth(),
+		MaxHealth: cfg.startHealth(),
+		Attack:    cfg.StartAttack,
+		Defense:   cfg.StartDefense,
+		BaseDefense: cfg.StartDefense,
+		Armor:     make(map[ArmorSlot]Item),
+		Accessories: make(map[AccessorySlot]Item),
 		Gold:      0,
 		Level:     1,
 		Exp:       0,
+		Mana:      cfg.StartMana,
+		MaxMana:   cfg.StartMana,
+		Spells:    []SpellType{SpellMagicMissile},
+		Hunger:    MaxHunger,
+		LightRadius: 8,
+		Perception:  2,
+		MaxCarry:    30,
 		Inventory: make([]Item, 0),
+		Stats:     Stats{Kills: make(map[string]int)},
+		Resistances: make(map[DamageType]int),
+		AutoPickup:  cfg.AutoPickup,
+		Config:      cfg,
+		Identities:  &Identities{Known: make(map[string]bool)},
+		Name:        defaultName,
 	}
+	p.applyClass(class)
+	return p
 }
 
 // Move attempts to move the player in the specified direction
-func (p *Player) Move(dx, dy int, d *Dungeon) {
+func (p *Player) Move(dir Direction, d *Dungeon) {
+	dx, dy := dir.Delta()
 	newX := p.X + dx
 	newY := p.Y + dy
 
+	// Bumping into a chest tries to unlock it
+	if d.GetTileAt(newX, newY) == Chest {
+		p.OpenChest(newX, newY, d)
+		return
+	}
+	
+	// Friendly creatures swap places rather than block the way
+	if enemy := d.GetEnemyAt(newX, newY); enemy != nil && !enemy.Hostile {
+		d.moveEnemy(enemy, p.X, p.Y)
+		p.X, p.Y = newX, newY
+		p.CheckPosition(d)
+		return
+	}
+	
 	// Check if there's an enemy at the target position
 	if enemy := d.GetEnemyAt(newX, newY); enemy != nil {
 		// Attack the enemy instead of moving
@@ -54,55 +122,211 @@ func (p *Player) Move(dx, dy int, d *Dungeon) {
 		// Check for items or special tiles at the new position
 		p.CheckPosition(d)
 	} else {
-		fmt.Println("You can't move there!")
+		p.Messages.Log("You can't move there!")
 	}
 }
 
+// UseTeleporter sends the player from the teleporter they are standing on
+// to its partner, unless something is standing there
+func (p *Player) UseTeleporter(d *Dungeon) {
+	to, ok := d.Teleporters[[2]int{p.X, p.Y}]
+	if !ok {
+		return
+	}
+	if d.GetEnemyAt(to[0], to[1]) != nil {
+		p.Messages.Log("The teleporter hums, but something blocks the other end.")
+		return
+	}
+	p.X, p.Y = to[0], to[1]
+	p.Messages.Log("The teleporter whisks you across the level!")
+}
+
+// Flee steps to the free neighbouring tile farthest from the nearest enemy,
+// diagonals included, without attacking anything. It returns true if the
+// player moved, which uses a turn.
+func (p *Player) Flee(d *Dungeon) bool {
+	if enemy, _ := d.NearestEnemy(p.X, p.Y); enemy == nil {
+		p.Messages.Log("There is nothing to flee from.")
+		return false
+	}
+	
+	bestX, bestY, best := 0, 0, -1
+	for _, dir := range allDirections {
+		dx, dy := dir.Delta()
+		x, y := p.X+dx, p.Y+dy
+		if !d.IsWalkable(x, y) || d.GetEnemyAt(x, y) != nil {
+			continue
+		}
+		if _, dist := d.NearestEnemy(x, y); dist > best {
+			bestX, bestY, best = x, y, dist
+		}
+	}
+	if best < 0 {
+		p.Messages.Log("You are cornered with nowhere to run!")
+		return false
+	}
+	
+	p.X, p.Y = bestX, bestY
+	p.Messages.Log("You flee!")
+	p.CheckPosition(d)
+	return true
+}
+
 // AttackEnemy handles combat with an enemy
 func (p *Player) AttackEnemy(enemy *Enemy, d *Dungeon) {
 	// Calculate damage dealt to enemy
-	damage := p.Attack
+	damage := rollDamage(d.Rng, p.Attack)
+	crit := p.CritChance > 0 && d.Rng.Intn(100) < p.CritChance
+	if crit {
+		damage *= 2
+		p.Messages.Log("A critical hit!")
+	}
+	if !enemy.Aware && !enemy.Disguised {
+		damage += damage * sneakAttackBonus / 100
+		p.Messages.Log("You catch %s unawares!", enemy.Title())
+	}
+	p.BreakStealth()
+	d.MakeNoise(p.X, p.Y, fightNoise)
 	
 	// Apply damage to enemy
-	enemy.Health -= damage
+	damage = p.DealDamage(enemy, damage, p.AttackType, d)
 	
-	fmt.Printf("You attack the %s for %d damage!\n", enemy.Name, damage)
+	p.Messages.Log("You attack %s for %d damage!", enemy.Title(), damage)
 	
-	// Check if enemy is defeated
+	// Check if enemy is defeated. Survivors strike back in the enemy attack phase.
 	if enemy.Health <= 0 {
-		fmt.Printf("You defeated the %s!\n", enemy.Name)
-		
-		// Award experience and possibly gold
-		expGain := 5 + enemy.Damage * 2
-		p.Exp += expGain
-		fmt.Printf("You gained %d experience points.\n", expGain)
-		
-		// Check for level up
-		p.CheckLevelUp()
-		
-		// Remove the enemy from the dungeon
-		d.RemoveEnemy(enemy)
-		
-		// 50% chance to drop gold
-		if rand.Intn(2) == 0 {
-			goldAmount := 1 + rand.Intn(10)
-			p.Gold += goldAmount
-			fmt.Printf("You found %d gold!\n", goldAmount)
-		}
-	} else {
-		// Enemy counterattack
-		enemyDamage := enemy.Damage - p.Defense
-		if enemyDamage < 1 {
-			enemyDamage = 1 // Minimum damage is 1
+		p.DefeatEnemy(enemy, d)
+		return
+	}
+	
+	// Heavy blows shove the enemy away
+	if crit || damage >= knockbackDamage {
+		p.knockback(enemy, sign(enemy.X-p.X), sign(enemy.Y-p.Y), d)
+	}
+}
+
+const (
+	knockbackDamage    = 7 // A blow dealing at least this much knocks the enemy back
+	knockbackCollision = 2 // Damage an enemy takes when knocked into something solid
+)
+
+// knockback shoves an enemy one tile along (dx, dy). If something is in the
+// way, it slams into it instead and takes collision damage. An enemy shoved
+// onto a trap or teleporter sets it off, just as if it had walked there.
+func (p *Player) knockback(e *Enemy, dx, dy int, d *Dungeon) {
+	x, y := e.X+dx, e.Y+dy
+	if d.canWalk(e, x, y) && d.GetEnemyAt(x, y) == nil && (x != p.X || y != p.Y) {
+		p.Messages.Log("The blow knocks %s back!", e.Title())
+		d.moveEnemy(e, x, y)
+		d.enemyEntered(e, p)
+		return
+	}
+	
+	obstacle := "the " + strings.ToLower(TileType(d.at(x, y)).Name())
+	if other := d.GetEnemyAt(x, y); other != nil && other.Disguised {
+		obstacle = "the treasure" // A mimic keeps up its act
+	} else if other != nil {
+		obstacle = other.Title()
+	}
+	e.Health -= knockbackCollision
+	p.Messages.Log("The blow slams %s into %s for %d damage!", e.Title(), obstacle, knockbackCollision)
+	if e.Health <= 0 {
+		p.DefeatEnemy(e, d)
+	}
+}
+
+// TakeDamage reduces the player's health and interrupts natural regeneration
+func (p *Player) TakeDamage(amount int) {
+	p.Health -= amount
+	p.TurnsSinceDamage = 0
+	p.Stats.DamageTaken += amount
+}
+
+// DealDamage reduces an enemy's health by an attack from the player, after the
+// enemy's resistance to the damage type, and returns the damage actually dealt.
+// Hitting a disguised mimic gives it away.
+func (p *Player) DealDamage(enemy *Enemy, amount int, damageType DamageType, d *Dungeon) int {
+	if enemy.Disguised {
+		d.Unmask(enemy)
+	}
+	amount = resist(amount, damageType, enemy.Resistances)
+	enemy.Health -= amount
+	p.Stats.DamageDealt += amount
+	
+	// Ice can freeze a surviving enemy in place
+	if damageType == Ice && amount > 0 && enemy.Health > 0 && d.Rng.Intn(100) < freezeChance {
+		enemy.FrozenTurns = freezeDuration
+		p.Messages.Log("%s is frozen solid!", capitalize(enemy.Title()))
+	}
+	return amount
+}
+
+// Regenerate slowly restores health while the player stays out of combat
+func (p *Player) Regenerate() {
+	p.TurnsSinceDamage++
+	
+	// Higher level players regenerate faster, down to 1 HP every 3 turns
+	interval := 10 - p.Level
+	if interval < 3 {
+		interval = 3
+	}
+	
+	// Rings of regeneration speed it up further
+	interval -= p.RegenBonus + p.SetRegen
+	if interval < 1 {
+		interval = 1
+	}
+	
+	if p.TurnsSinceDamage%interval == 0 && p.Health > 0 && p.Health < p.MaxHealth {
+		p.Health++
+	}
+}
+
+// DefeatEnemy awards experience and loot for a slain enemy and removes it
+func (p *Player) DefeatEnemy(enemy *Enemy, d *Dungeon) {
+	p.Messages.Log("You defeated %s!", enemy.Title())
+	p.Stats.Kills[enemy.Name]++
+	
+	// Award experience and possibly gold, more for elites
+	expGain := 5 + enemy.Damage * 2
+	if enemy.Elite {
+		expGain *= 2
+	}
+	p.GainExp(expGain)
+	
+	// Remove the enemy from the dungeon, letting it leave something behind
+	d.RemoveEnemy(enemy)
+	if hook, ok := enemy.Behavior.(DeathBehavior); ok {
+		hook.OnDeath(enemy, d)
+	}
+	
+	// 50% chance to drop gold, and elites always carry some extra
+	if enemy.Elite || d.Rng.Intn(2) == 0 {
+		goldAmount := 1 + d.Rng.Intn(10)
+		if enemy.Elite {
+			goldAmount += 10
 		}
-		
-		p.Health -= enemyDamage
-		fmt.Printf("The %s attacks you for %d damage!\n", enemy.Name, enemyDamage)
-		
-		// Check if player is defeated
-		if p.Health <= 0 {
-			fmt.Println("You have been defeated! Game over.")
+		p.Gold += goldAmount
+		p.Messages.Log("%s found %d gold!", p.Name, goldAmount)
+	}
+	
+	// Thieves drop whatever they stole
+	if enemy.StolenGold > 0 {
+		d.AddItem(NewGold(enemy.X, enemy.Y, enemy.StolenGold))
+		p.Messages.Log("%s drops the %d gold it stole from you.", capitalize(enemy.Title()), enemy.StolenGold)
+	}
+	
+	// Bosses always leave behind a piece of rare or better equipment
+	if enemy.Boss {
+		rarity := rollRarity(d.Rng, d.Level, Rare)
+		if d.Rng.Intn(2) == 0 {
+			kind := weaponTypes[d.Rng.Intn(len(weaponTypes))]
+			d.AddItem(NewWeapon(enemy.X, enemy.Y, kind.name, kind.value, rarity))
+		} else {
+			kind := armorTypes[d.Rng.Intn(len(armorTypes))]
+			d.AddItem(NewArmor(enemy.X, enemy.Y, kind.name, kind.value, kind.slot, rarity))
 		}
+		p.Messages.Log("%s drops something as it falls.", enemy.Name)
 	}
 }
 
@@ -112,73 +336,293 @@ func (p *Player) CheckPosition(d *Dungeon) {
 	tile := d.GetTileAt(p.X, p.Y)
 	
 	switch tile {
-	case Treasure:
-		// Collect treasure
-		p.Gold += 10 + rand.Intn(20)
-		fmt.Printf("You found some gold! You now have %d gold.\n", p.Gold)
-		d.Grid[p.Y][p.X] = rune(Floor) // Replace with floor
-		
-	case Trap:
-		// Trigger trap
-		damage := 2 + rand.Intn(3)
-		p.Health -= damage
-		fmt.Printf("You triggered a trap! You take %d damage.\n", damage)
-		d.Grid[p.Y][p.X] = rune(Floor) // Trap is now disarmed
-		
-		// Check if player died from trap
-		if p.Health <= 0 {
-			fmt.Println("You died from a trap! Game over.")
-		}
-		
 	case Door:
-		// Open door
-		fmt.Println("You open the door.")
-		d.Grid[p.Y][p.X] = rune(Floor) // Door is now open
+		// Open door, quietly if sneaking
+		if p.Sneaking {
+			p.Messages.Log("You ease the door open.")
+		} else {
+			p.Messages.Log("The door bangs open.")
+			d.MakeNoise(p.X, p.Y, doorNoise)
+		}
+		d.set(p.X, p.Y, rune(Floor)) // Door is now open
 		
 	case StairsDown:
 		// Go to next level
-		fmt.Println("You found stairs leading down! Press '>' to descend to the next level.")
+		p.Messages.Log("You found stairs leading down! Press '>' to descend to the next level.")
+		
+	case Teleporter:
+		// Whisk the player to the other end
+		p.UseTeleporter(d)
+	}
+	
+	// Check for traps, which may be hidden under the floor
+	if d.GetTrapAt(p.X, p.Y) != nil {
+		p.TriggerTrap(p.X, p.Y, d)
+	}
+	
+	// Show how any equipment here compares with what is equipped
+	if item := d.GetItemAt(p.X, p.Y); item != nil {
+		if comparison := p.Compare(*item); comparison != "" {
+			p.Messages.Log(comparison)
+		}
 	}
 	
-	// Check for items
+	// Check for treasure and items
+	if p.AutoPickup {
+		p.PickUp(d)
+		return
+	}
+	if tile == Treasure {
+		p.Messages.Log("There is treasure here.")
+	}
+	if item := d.GetItemAt(p.X, p.Y); item != nil {
+		p.Messages.Log("You see a %s here.", p.Identities.Name(*item))
+	}
+}
+
+// PickUp collects any treasure and item on the player's tile.
+// It returns true if there was anything to pick up.
+func (p *Player) PickUp(d *Dungeon) bool {
+	found := false
+	
+	// Collect treasure
+	if d.GetTileAt(p.X, p.Y) == Treasure {
+		p.Gold += 10 + d.Rng.Intn(20)
+		p.Messages.Log("%s found some gold! You now have %d gold.", p.Name, p.Gold)
+		d.set(p.X, p.Y, rune(Floor)) // Replace with floor
+		found = true
+	}
+	
+	// Collect items
 	if item := d.GetItemAt(p.X, p.Y); item != nil {
 		p.CollectItem(item)
+		found = true
+	}
+	return found
+}
+
+// TriggerTrap sets off the trap at (x, y), damaging the player or, for a
+// pit, marking them as falling to the next level
+func (p *Player) TriggerTrap(x, y int, d *Dungeon) {
+	if trap := d.GetTrapAt(x, y); trap != nil && trap.Kind == TrapPit {
+		damage := 1 + d.Rng.Intn(d.Config.TrapDamageMin)
+		p.TakeDamage(damage)
+		p.Stats.TrapsTriggered++
+		p.Messages.Log("You fall through a pit! You take %d damage.", damage)
+		d.RemoveTrap(x, y)
+		if p.Health <= 0 {
+			p.Messages.Log("You died from the fall! Game over.")
+		} else {
+			p.Falling = true
+		}
+		return
+	}
+	if trap := d.GetTrapAt(x, y); trap != nil && trap.Kind == TrapAlarm {
+		p.Stats.TrapsTriggered++
+		p.Messages.Log("An alarm blares! Something stirs in the dark.")
+		p.BreakStealth()
+		d.RemoveTrap(x, y)
+		for i := 0; i < 2; i++ {
+			spawnEnemyNearPlayer(p, d)
+		}
+		d.MakeNoise(x, y, alarmRadius)
+		return
+	}
+	
+	damage := d.Config.TrapDamageMin + d.Rng.Intn(d.Config.TrapDamageMax-d.Config.TrapDamageMin+1)
+	p.TakeDamage(damage)
+	p.Stats.TrapsTriggered++
+	p.Messages.Log("You triggered a trap! You take %d damage.", damage)
+	d.RemoveTrap(x, y) // Trap is now disarmed
+	
+	// Check if player died from trap
+	if p.Health <= 0 {
+		p.Messages.Log("You died from a trap! Game over.")
+	}
+}
+
+// alarmRadius is how far away enemies hear an alarm trap
+const alarmRadius = 12
+
+// DetectTraps gives the player a chance to notice traps within their perception radius
+func (p *Player) DetectTraps(d *Dungeon) {
+	for pos, trap := range d.Traps {
+		if !trap.Hidden || abs(pos[0]-p.X) > p.Perception || abs(pos[1]-p.Y) > p.Perception {
+			continue
+		}
+		
+		// 50% chance each turn to spot a trap in range
+		if d.Rng.Intn(2) == 0 {
+			d.RevealTrap(pos[0], pos[1])
+			p.Messages.Log("You notice a trap nearby!")
+		}
+	}
+}
+
+// Search carefully examines the adjacent tiles for hidden traps and secret doors
+func (p *Player) Search(d *Dungeon) {
+	found := false
+	for y := p.Y - 1; y <= p.Y+1; y++ {
+		for x := p.X - 1; x <= p.X+1; x++ {
+			// 1/3 chance to find each adjacent secret door
+			if d.SecretDoors[[2]int{x, y}] && d.Rng.Intn(3) == 0 {
+				d.RevealSecretDoor(x, y)
+				p.Messages.Log("You find a secret door!")
+				found = true
+			}
+			
+			trap := d.GetTrapAt(x, y)
+			if trap == nil || !trap.Hidden {
+				continue
+			}
+			
+			// 75% chance to find each adjacent hidden trap
+			if d.Rng.Intn(100) < 75 {
+				d.RevealTrap(x, y)
+				p.Messages.Log("You find a hidden trap!")
+				found = true
+			}
+		}
+	}
+	
+	if !found {
+		p.Messages.Log("You search the area but find nothing.")
+	}
+}
+
+// Disarm attempts to disarm a detected trap at (x, y)
+func (p *Player) Disarm(x, y int, d *Dungeon) {
+	if trap := d.GetTrapAt(x, y); trap == nil || trap.Hidden {
+		p.Messages.Log("There is no trap there that you know of.")
+		return
+	}
+	
+	// Success chance improves with the player's level
+	chance := 50 + 5*p.Level
+	if d.Rng.Intn(100) < chance {
+		d.RemoveTrap(x, y)
+		p.Messages.Log("You carefully disarm the trap.")
+		
+		// Award a little experience for the effort
+		p.GainExp(10)
+	} else {
+		p.Messages.Log("You fumble the mechanism!")
+		p.TriggerTrap(x, y, d)
 	}
 }
 
 // CollectItem adds an item to the player's inventory
 func (p *Player) CollectItem(item *Item) {
+	// Leave the item on the floor if it would overload the player
+	if item.Type != ItemGold && item.Type != ItemTreasure && p.CarryWeight()+item.Weight > p.MaxCarry {
+		p.Messages.Log("The %s is too heavy to carry. You leave it on the floor.", p.Identities.Name(*item))
+		return
+	}
+	
 	// Mark the item as collected
 	item.Collected = true
+	p.Stats.ItemsCollected++
 	
 	// Handle different item types
 	switch item.Type {
 	case ItemGold:
 		p.Gold += item.Value
-		fmt.Printf("You collected %d gold! You now have %d gold.\n", item.Value, p.Gold)
+		p.Messages.Log("%s collected %d gold! You now have %d gold.", p.Name, item.Value, p.Gold)
 		
-	case ItemPotion:
+	case ItemPotion, ItemFirePotion, ItemStrengthPotion:
+		// Add to inventory
+		p.Inventory = append(p.Inventory, *item)
+		p.Messages.Log("You picked up a %s.", p.Identities.Name(*item))
+		
+	case ItemFood:
+		// Add to inventory
+		p.Inventory = append(p.Inventory, *item)
+		p.Messages.Log("You picked up a %s.", p.Identities.Name(*item))
+		
+	case ItemTorch:
 		// Add to inventory
 		p.Inventory = append(p.Inventory, *item)
-		fmt.Printf("You picked up a %s.\n", item.Name)
+		p.Messages.Log("You picked up a %s.", p.Identities.Name(*item))
+		
+	case ItemSpellbook:
+		// Add to inventory
+		p.Inventory = append(p.Inventory, *item)
+		p.Messages.Log("You picked up a %s.", p.Identities.Name(*item))
 		
 	case ItemWeapon:
 		// Add to inventory
 		p.Inventory = append(p.Inventory, *item)
-		fmt.Printf("You picked up a %s.\n", item.Name)
+		p.Messages.Log("You picked up a %s.", p.Identities.Name(*item))
 		
 	case ItemArmor:
 		// Add to inventory
 		p.Inventory = append(p.Inventory, *item)
-		fmt.Printf("You picked up a %s.\n", item.Name)
+		p.Messages.Log("You picked up a %s.", p.Identities.Name(*item))
+		
+	case ItemRing, ItemPendant, ItemScroll, ItemWand, ItemBomb:
+		// Add to inventory
+		p.Inventory = append(p.Inventory, *item)
+		p.Messages.Log("You picked up a %s.", p.Identities.Name(*item))
+		
+	case ItemAmulet:
+		// Add to inventory
+		p.Inventory = append(p.Inventory, *item)
+		p.Messages.Log("You picked up the %s! Your quest is complete.", item.Name)
+	}
+}
+
+// HasItem reports whether the player carries an item of the given type
+func (p *Player) HasItem(itemType ItemType) bool {
+	for _, item := range p.Inventory {
+		if item.Type == itemType {
+			return true
+		}
+	}
+	return false
+}
+
+// CarryWeight returns the total weight of the player's inventory
+func (p *Player) CarryWeight() int {
+	weight := 0
+	for _, item := range p.Inventory {
+		weight += item.Weight
+	}
+	if p.Weapon != nil {
+		weight += p.Weapon.Weight
+	}
+	for _, item := range p.Armor {
+		weight += item.Weight
+	}
+	for _, item := range p.Accessories {
+		weight += item.Weight
+	}
+	return weight
+}
+
+// DropItem drops an item from the inventory onto the player's tile
+func (p *Player) DropItem(itemIndex int, d *Dungeon) {
+	// Check if the index is valid
+	if itemIndex < 0 || itemIndex >= len(p.Inventory) {
+		p.Messages.Log("Invalid item index.")
+		return
 	}
+	
+	// Put the item back into the dungeon
+	item := p.Inventory[itemIndex]
+	item.X, item.Y = p.X, p.Y
+	item.Collected = false
+	d.AddItem(item)
+	
+	// Remove the item from inventory
+	p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+	p.Messages.Log("You drop the %s.", p.Identities.Name(item))
 }
 
 // UseItem uses an item from the inventory
-func (p *Player) UseItem(itemIndex int) {
+func (p *Player) UseItem(itemIndex int, d *Dungeon) {
 	// Check if the index is valid
 	if itemIndex < 0 || itemIndex >= len(p.Inventory) {
-		fmt.Println("Invalid item index.")
+		p.Messages.Log("Invalid item index.")
 		return
 	}
 	
@@ -188,64 +632,535 @@ func (p *Player) UseItem(itemIndex int) {
 	// Handle different item types
 	switch item.Type {
 	case ItemPotion:
-		// Heal the player
+		// Heal the player, learning what the potion is
+		p.identify(item)
 		healAmount := item.Value
 		p.Health += healAmount
 		if p.Health > p.MaxHealth {
 			p.Health = p.MaxHealth
 		}
-		fmt.Printf("You drink the %s and heal for %d health points.\n", item.Name, healAmount)
+		p.Messages.Log("You drink the %s and heal for %d health points.", item.Name, healAmount)
 		
 		// Remove the item from inventory
 		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
 		
+	case ItemStrengthPotion:
+		// Strength stays with the player whatever weapon they wield
+		p.identify(item)
+		p.Strength += item.Value
+		p.Attack += item.Value
+		p.Messages.Log("You drink the %s and feel stronger! Your attack is now %d.", item.Name, p.Attack)
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		
+	case ItemFirePotion:
+		// Drinking fire is never a good idea, but only known potions can be refused
+		if p.Identities.Identified(item) {
+			p.Messages.Log("The %s is meant to be thrown, not drunk.", item.Name)
+			return
+		}
+		p.identify(item)
+		damage := item.Value / 2
+		p.TakeDamage(damage)
+		p.Messages.Log("You drink the potion and flames burst in your mouth! You take %d damage.", damage)
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		
+	case ItemFood:
+		// Eat the food
+		p.Hunger += item.Value
+		if p.Hunger > MaxHunger {
+			p.Hunger = MaxHunger
+		}
+		p.Messages.Log("You eat the %s. You feel %s.", item.Name, strings.ToLower(p.HungerState()))
+		
+		// Remove the item from inventory
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		
+	case ItemTorch:
+		// Light the torch, replacing any torch already burning
+		p.TorchTurns = item.Value
+		p.Messages.Log("You light the %s. The darkness recedes.", item.Name)
+		
+		// Remove the item from inventory
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		
+	case ItemSpellbook:
+		// Learn the spell contained in the book
+		spell := SpellType(item.Value)
+		if p.KnowsSpell(spell) {
+			p.Messages.Log("You already know %s.", spells[spell].Name)
+			return
+		}
+		p.Spells = append(p.Spells, spell)
+		p.Messages.Log("You study the %s and learn to cast %s!", item.Name, spells[spell].Name)
+		
+		// The book crumbles to dust once read
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		
 	case ItemWeapon:
-		// Equip the weapon
-		p.Attack = item.Value
-		fmt.Printf("You equip the %s. Your attack is now %d.\n", item.Name, p.Attack)
+		// Wield the weapon, putting away the old one unless it is cursed
+		if p.Weapon != nil && p.Weapon.Cursed {
+			p.Messages.Log("You can't let go of the %s - it is cursed!", p.Weapon.Name)
+			return
+		}
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		held := 0
+		if p.Weapon != nil {
+			held = p.Weapon.Value
+			p.Inventory = append(p.Inventory, *p.Weapon)
+			p.Messages.Log("You put away the %s.", p.Weapon.Name)
+		}
+		
+		// Swap the old weapon's bonus for the new one's, keeping the base
+		// attack and any bonus from levels, rings, and potions of strength
+		p.Attack += item.Value - held
+		p.AttackType = item.Element
+		p.Messages.Log("You equip the %s. Your attack is now %d.", item.Name, p.Attack)
+		if item.Cursed {
+			item.Description = cursedDescription
+			p.Messages.Log("The %s welds itself to your hand. It is cursed!", item.Name)
+		}
+		p.Weapon = &item
 		
 	case ItemArmor:
-		// Equip the armor
-		p.Defense = item.Value
-		fmt.Printf("You equip the %s. Your defense is now %d.\n", item.Name, p.Defense)
+		// Wear the armor, putting back whatever was in its slot unless it is cursed
+		if old, ok := p.Armor[item.Slot]; ok && old.Cursed {
+			p.Messages.Log("You can't take off the %s - it is cursed!", old.Name)
+			return
+		}
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		if old, ok := p.Armor[item.Slot]; ok {
+			p.Inventory = append(p.Inventory, old)
+			p.Messages.Log("You take off the %s.", old.Name)
+		}
+		if item.Cursed {
+			item.Description = cursedDescription
+		}
+		p.Armor[item.Slot] = item
+		p.updateArmor()
+		p.Messages.Log("You put on the %s. Your defense is now %d.", item.Name, p.Defense)
+		if item.Cursed {
+			p.Messages.Log("The %s fuses to your body! It is cursed.", item.Name)
+		}
+		
+	case ItemRing, ItemPendant:
+		// Wear the accessory for its passive bonus
+		p.WearAccessory(itemIndex)
+		
+	case ItemScroll:
+		// Read the scroll, which crumbles to dust
+		p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+		p.identify(item)
+		p.ReadScroll(ScrollType(item.Value), d)
+		
+	case ItemWand:
+		p.Messages.Log("Wands are zapped, not used. Press 'z' to zap the %s.", item.Name)
+		
+	case ItemKey:
+		p.Messages.Log("Walk into a locked chest to open it with the %s.", item.Name)
+		
+	case ItemBomb:
+		p.Messages.Log("Throw the %s to set it off: 't <item> <direction>' from the inventory.", item.Name)
+		
+	case ItemAmulet:
+		p.Messages.Log("The %s glows warmly in your hands.", item.Name)
 	}
 }
 
-// CheckLevelUp checks if the player has enough experience to level up
-func (p *Player) CheckLevelUp() {
-	// Simple level up formula: 100 * current level
-	expNeeded := 100 * p.Level
+// ReadScroll invokes the magic of a scroll
+func (p *Player) ReadScroll(kind ScrollType, d *Dungeon) {
+	switch kind {
+	case ScrollMagicMapping:
+		d.MapLevel()
+		p.Messages.Log("You read the scroll. A map of the level forms in your mind.")
+		
+	case ScrollTeleport:
+		x, y, ok := d.RandomFloor(p)
+		if !ok {
+			p.Messages.Log("You read the scroll, but nothing happens.")
+			return
+		}
+		p.X, p.Y = x, y
+		p.Messages.Log("You read the scroll and vanish, reappearing elsewhere on the level!")
+		p.CheckPosition(d)
+		
+	case ScrollIdentify:
+		p.Messages.Log("You read the scroll. Your possessions seem clearer.")
+		p.identifyInventory()
+		
+	case ScrollRemoveCurse:
+		p.Messages.Log("You read the scroll. A weight lifts from your shoulders.")
+		p.removeCurses()
+		
+	case ScrollDescent:
+		p.Messages.Log("You read the scroll and sink through the floor!")
+		p.Descending = true
+	}
+}
+
+// removeCurses lifts the curse from everything the player has equipped, so it
+// can be taken off again. The cursed items stay as poor as they are.
+func (p *Player) removeCurses() {
+	if p.Weapon != nil {
+		p.Weapon.Cursed = false
+	}
+	for slot, item := range p.Armor {
+		item.Cursed = false
+		p.Armor[slot] = item
+	}
+	for slot, item := range p.Accessories {
+		item.Cursed = false
+		p.Accessories[slot] = item
+	}
+}
+
+// updateArmor recalculates Defense from the base value and every worn piece
+// of armor, along with the bonuses of any armor sets being worn
+func (p *Player) updateArmor() {
+	p.Defense = p.BaseDefense
+	for _, item := range p.Armor {
+		p.Defense += item.Value
+	}
+	
+	p.SetRegen = 0
+	for _, n := range p.setCounts() {
+		if n >= 2 {
+			p.Defense += setDefenseBonus
+		}
+		if n >= 3 {
+			p.SetRegen += setRegenBonus
+		}
+	}
+}
+
+// WeaponLine describes the weapon being wielded
+func (p *Player) WeaponLine() string {
+	if p.Weapon == nil {
+		return "Wielding: bare hands"
+	}
+	return fmt.Sprintf("Wielding: %s (%s)", p.Weapon.Name, p.Weapon.Description)
+}
+
+// ItemLabel names an inventory item as the player knows it, with a wand's charges
+func (p *Player) ItemLabel(item Item) string {
+	if item.Type == ItemWand {
+		return item.Name + " [" + chargesLabel(item.Charges) + "]"
+	}
+	return p.Identities.Name(item)
+}
+
+// ArmorLines describes what is worn in each armor slot
+func (p *Player) ArmorLines() []string {
+	lines := make([]string, 0, len(armorSlots))
+	for _, slot := range armorSlots {
+		if item, ok := p.Armor[slot]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s (%+d)", slot.Name(), item.Name, item.Value))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: nothing", slot.Name()))
+		}
+	}
+	return lines
+}
+
+// QuickHeal drinks the strongest health potion the player knows they have
+func (p *Player) QuickHeal(d *Dungeon) {
+	best, unknown := -1, false
+	for i, item := range p.Inventory {
+		if item.Type != ItemPotion {
+			continue
+		}
+		if !p.Identities.Identified(item) {
+			unknown = true
+			continue
+		}
+		if best < 0 || item.Value > p.Inventory[best].Value {
+			best = i
+		}
+	}
+	
+	if best < 0 {
+		if unknown {
+			p.Messages.Log("You don't know which of your potions heals.")
+		} else {
+			p.Messages.Log("No potions.")
+		}
+		return
+	}
+	p.UseItem(best, d)
+}
+
+// ThrowItem throws an item from the inventory in the given direction,
+// hitting the first hostile enemy along the line and sailing over allies.
+// Bombs explode where they stop.
+func (p *Player) ThrowItem(itemIndex int, dir Direction, d *Dungeon) {
+	// Check if the index is valid
+	if itemIndex < 0 || itemIndex >= len(p.Inventory) {
+		p.Messages.Log("Invalid item index.")
+		return
+	}
 	
-	if p.Exp >= expNeeded {
+	// Only potions and bombs can be thrown
+	item := p.Inventory[itemIndex]
+	if item.Type != ItemPotion && item.Type != ItemFirePotion && item.Type != ItemStrengthPotion && item.Type != ItemBomb {
+		p.Messages.Log("You can't throw the %s.", item.Name)
+		return
+	}
+	
+	// The thrown item is consumed whatever it hits
+	p.Inventory = append(p.Inventory[:itemIndex], p.Inventory[itemIndex+1:]...)
+	p.Messages.Log("You throw the %s.", p.Identities.Name(item))
+	
+	// Follow the line of flight, skipping the player's own tile
+	const throwRange = 6
+	dx, dy := dir.Delta()
+	path := line(p.X, p.Y, p.X+dx*throwRange, p.Y+dy*throwRange)
+	landX, landY := p.X, p.Y
+	for _, pos := range path[1:] {
+		x, y := pos[0], pos[1]
+		
+		// Stop when the potion hits a wall
+		if !d.IsWalkable(x, y) {
+			break
+		}
+		landX, landY = x, y
+		
+		// Check if the potion hits an enemy; allies are thrown over
+		if enemy := d.GetEnemyAt(x, y); enemy != nil && enemy.Hostile {
+			if item.Type == ItemBomb {
+				p.explode(item, x, y, d)
+			} else if item.Type == ItemFirePotion {
+				p.identify(item)
+				damage := p.DealDamage(enemy, item.Value, item.Element, d)
+				p.Messages.Log("The %s bursts into flames, burning %s for %d damage!", item.Name, enemy.Title(), damage)
+				d.Ignite(x, y, fireDuration)
+				if enemy.Health <= 0 {
+					p.DefeatEnemy(enemy, d)
+				}
+			} else {
+				p.Messages.Log("The %s shatters harmlessly on %s.", p.Identities.Name(item), enemy.Title())
+			}
+			return
+		}
+	}
+	
+	if item.Type == ItemBomb {
+		p.explode(item, landX, landY, d)
+		return
+	}
+	if item.Type == ItemFirePotion {
+		p.identify(item)
+		p.Messages.Log("The %s shatters and bursts into flames.", item.Name)
+		d.Ignite(landX, landY, fireDuration)
+		return
+	}
+	p.Messages.Log("The %s shatters on the ground.", p.Identities.Name(item))
+}
+
+// UpdateHunger makes the player hungrier and applies starvation damage
+func (p *Player) UpdateHunger() {
+	if p.Hunger > 0 {
+		p.Hunger--
+		if p.Hunger == 100 {
+			p.Messages.Log("You are getting hungry.")
+		}
+		return
+	}
+	
+	// Starving players slowly lose health
+	p.TakeDamage(1)
+	p.Messages.Log("You are starving!")
+	if p.Health <= 0 {
+		p.Messages.Log("You starved to death! Game over.")
+	}
+}
+
+// Poison poisons the player for the given number of turns, unless they resist it
+func (p *Player) Poison(turns int) {
+	if p.Resistances[Poison] >= 100 {
+		return
+	}
+	if p.PoisonTurns == 0 {
+		p.Messages.Log("You have been poisoned!")
+	}
+	if turns > p.PoisonTurns {
+		p.PoisonTurns = turns
+	}
+}
+
+// UpdatePoison makes poison sap the player's health each turn until it wears off
+func (p *Player) UpdatePoison() {
+	if p.PoisonTurns == 0 {
+		return
+	}
+	p.PoisonTurns--
+	p.TakeDamage(1)
+	p.Messages.Log("The poison burns in your veins.")
+	if p.Health <= 0 {
+		p.Messages.Log("You succumbed to poison! Game over.")
+	} else if p.PoisonTurns == 0 {
+		p.Messages.Log("The poison wears off.")
+	}
+}
+
+// HungerState describes how hungry the player is
+func (p *Player) HungerState() string {
+	switch {
+	case p.Hunger == 0:
+		return "Starving"
+	case p.Hunger <= 100:
+		return "Hungry"
+	default:
+		return "Full"
+	}
+}
+
+// torchBonus is how much a lit torch extends the light radius
+const torchBonus = 4
+
+// SightRadius returns how far the player can see on the given dungeon level
+func (p *Player) SightRadius(depth int) int {
+	// The deeper levels are darker
+	radius := p.LightRadius - (depth-1)/2
+	if radius < 3 {
+		radius = 3
+	}
+	
+	if p.TorchTurns > 0 {
+		radius += torchBonus
+	}
+	return radius
+}
+
+// BurnTorch burns down the lit torch by one turn
+func (p *Player) BurnTorch() {
+	if p.TorchTurns == 0 {
+		return
+	}
+	
+	p.TorchTurns--
+	if p.TorchTurns == 0 {
+		p.Messages.Log("Your torch sputters out.")
+	}
+}
+
+// GainExp awards experience points and checks for a level up
+func (p *Player) GainExp(amount int) {
+	p.Exp += amount
+	p.Messages.Log("You gained %d experience points.", amount)
+	p.CheckLevelUp()
+}
+
+// ExploreRoom rewards the player for stepping into a room they haven't been in before
+func (p *Player) ExploreRoom(d *Dungeon) {
+	if d.VisitRoom(p.X, p.Y) && p.Config.ExploreExp > 0 {
+		p.Messages.Log("You explore a new room.")
+		p.GainExp(p.Config.ExploreExp)
+	}
+}
+
+// CheckLevelUp checks if the player has enough experience to level up. Only
+// so many levels are gained at once; any experience left over counts
+// towards the next one.
+func (p *Player) CheckLevelUp() {
+	for gained := 0; p.Config.MaxLevelUps <= 0 || gained < p.Config.MaxLevelUps; gained++ {
+		expNeeded := p.ExpToLevel()
+		if p.Exp < expNeeded {
+			return
+		}
 		p.Level++
 		p.Exp -= expNeeded
 		p.MaxHealth += 5
 		p.Health = p.MaxHealth
 		p.Attack++
 		
-		fmt.Printf("Level up! You are now level %d.\n", p.Level)
-		fmt.Printf("Your health increased to %d and your attack increased to %d.\n", p.MaxHealth, p.Attack)
-		
-		// Check if there's another level up available
-		p.CheckLevelUp()
+		p.Messages.Log("Level up! You are now level %d.", p.Level)
+		p.Messages.Log("Your health increased to %d and your attack increased to %d.", p.MaxHealth, p.Attack)
+	}
+}
+
+// ExpToLevel returns the experience needed to reach the next level, following
+// the configured curve. Unknown curves, or a table with no entries, fall back
+// to the linear one.
+func (p *Player) ExpToLevel() int {
+	cfg := p.Config
+	switch cfg.ExpCurve {
+	case "quadratic":
+		return cfg.ExpPerLevel * p.Level * p.Level
+	case "table":
+		if n := len(cfg.ExpTable); n > 0 {
+			return cfg.ExpTable[min(p.Level, n)-1]
+		}
+	}
+	// A fixed amount times the current level
+	return cfg.ExpPerLevel * p.Level
+}
+
+// StatusLine describes the player's current stats and the number of turns played
+func (p *Player) StatusLine(turns int) string {
+	status := fmt.Sprintf("Health: %d/%d | Mana: %d/%d | Attack: %d | Defense: %d | Gold: %d | Level: %d | Exp: %d/%d | %s | Turn: %d",
+		p.Health, p.MaxHealth, p.Mana, p.MaxMana, p.Attack, p.Defense, p.Gold, p.Level, p.Exp, p.ExpToLevel(), p.HungerState(), turns)
+	if p.Sneaking {
+		status += " | Sneaking"
+	}
+	return status
+}
+
+// LowHealth reports whether the player is below a quarter of their maximum health
+func (p *Player) LowHealth() bool {
+	return p.Health*4 < p.MaxHealth
+}
+
+// EquipmentLine briefly names the weapon and armor the player has equipped
+func (p *Player) EquipmentLine() string {
+	wield := "bare hands"
+	if p.Weapon != nil {
+		wield = p.Weapon.Name
+	}
+	var worn []string
+	for _, slot := range armorSlots {
+		if item, ok := p.Armor[slot]; ok {
+			worn = append(worn, item.Name)
+		}
 	}
+	wear := "no armor"
+	if len(worn) > 0 {
+		wear = strings.Join(worn, ", ")
+	}
+	return "Wield: " + wield + " | Wear: " + wear
 }
 
-// DisplayStatus shows the player's current stats
-func (p *Player) DisplayStatus() {
-	fmt.Printf("Health: %d/%d | Attack: %d | Defense: %d | Gold: %d | Level: %d | Exp: %d/%d\n",
-		p.Health, p.MaxHealth, p.Attack, p.Defense, p.Gold, p.Level, p.Exp, 100*p.Level)
+// DisplayStatus shows the player's current stats and the number of turns played
+func (p *Player) DisplayStatus(w io.Writer, turns int) {
+	fmt.Fprintln(w, p.StatusLine(turns))
+	fmt.Fprintln(w, p.EquipmentLine())
 }
 
-// DisplayInventory shows the player's inventory
-func (p *Player) DisplayInventory() {
+// DisplayInventory shows the player's equipment and the inventory items the
+// filter lets through, numbered by their place in the whole inventory. With
+// color on, uncommon and better items are colored by rarity.
+func (p *Player) DisplayInventory(w io.Writer, filter string, color bool) {
+	fmt.Fprintln(w, p.WeaponLine())
+	fmt.Fprintln(w, "Worn: "+strings.Join(p.ArmorLines(), " | "))
+	fmt.Fprintln(w, "Accessories: "+strings.Join(p.AccessoryLines(), " | "))
+	for _, line := range p.SetBonusLines() {
+		fmt.Fprintln(w, line)
+	}
 	if len(p.Inventory) == 0 {
-		fmt.Println("Your inventory is empty.")
+		fmt.Fprintln(w, "Your inventory is empty.")
 		return
 	}
 	
-	fmt.Println("Inventory:")
+	fmt.Fprintf(w, "Inventory (weight %d/%d):\n", p.CarryWeight(), p.MaxCarry)
+	if filter != "" {
+		fmt.Fprintf(w, "Showing %s only ('f all' to show everything)\n", filter)
+	}
 	for i, item := range p.Inventory {
-		fmt.Printf("%d. %s (%s)\n", i+1, item.Name, item.Description)
+		if !matchesFilter(item, filter) {
+			continue
+		}
+		label := p.ItemLabel(item)
+		if code := rarityColor(item.Rarity); code != "" && color {
+			label = code + label + "\x1b[0m"
+		}
+		fmt.Fprintf(w, "%d. %s (%s) [wt %d]\n", i+1, label, p.Identities.Description(item), item.Weight)
 	}
 }
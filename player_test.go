@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLevelUpThreshold(t *testing.T) {
+	curves := []struct {
+		curve string
+		table []int
+		level int
+		need  int
+	}{
+		{"linear", nil, 1, 100},
+		{"linear", nil, 3, 300},
+		{"quadratic", nil, 3, 900},
+		{"table", []int{50, 120, 200}, 2, 120},
+		{"table", []int{50, 120, 200}, 5, 200}, // The last entry repeats
+	}
+	for _, tt := range curves {
+		name := fmt.Sprintf("%s at level %d", tt.curve, tt.level)
+		t.Run(name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ExpCurve, cfg.ExpTable = tt.curve, tt.table
+			p := NewPlayer(0, 0, cfg, ClassWarrior)
+			p.Level = tt.level
+			if got := p.ExpToLevel(); got != tt.need {
+				t.Fatalf("ExpToLevel() = %d, want %d", got, tt.need)
+			}
+
+			p.GainExp(tt.need - 1)
+			if p.Level != tt.level || p.Exp != tt.need-1 {
+				t.Errorf("one short of the threshold: level %d with %d exp, want level %d with %d", p.Level, p.Exp, tt.level, tt.need-1)
+			}
+			p.GainExp(2)
+			if p.Level != tt.level+1 || p.Exp != 1 {
+				t.Errorf("one past the threshold: level %d with %d exp, want level %d with 1", p.Level, p.Exp, tt.level+1)
+			}
+			if want := fmt.Sprintf("Exp: 1/%d", p.ExpToLevel()); !strings.Contains(p.StatusLine(0), want) {
+				t.Errorf("status line %q doesn't show %q", p.StatusLine(0), want)
+			}
+		})
+	}
+}
+
+func TestLevelUpsPerAwardAreCapped(t *testing.T) {
+	cfg := DefaultConfig()
+	p := NewPlayer(0, 0, cfg, ClassWarrior)
+	p.GainExp(1000)
+	if p.Level != 2 || p.Exp != 900 {
+		t.Errorf("1000 exp at once gave level %d with %d exp, want level 2 with 900 left over", p.Level, p.Exp)
+	}
+
+	cfg.MaxLevelUps = 0
+	p = NewPlayer(0, 0, cfg, ClassWarrior)
+	p.GainExp(1000)
+	if p.Level != 5 || p.Exp != 0 {
+		t.Errorf("1000 exp with no cap gave level %d with %d exp, want level 5 with none left over", p.Level, p.Exp)
+	}
+}
+
+func TestInventoryRarityColor(t *testing.T) {
+	p := NewPlayer(0, 0, DefaultConfig(), ClassWarrior)
+	epic := NewWeapon(0, 0, "Sword", 5, Epic)
+	plain := NewWeapon(0, 0, "Club", 2, Common)
+	p.Inventory = []Item{epic, plain}
+
+	var b strings.Builder
+	p.DisplayInventory(&b, "", true)
+	if !strings.Contains(b.String(), "\x1b[35m"+epic.Name+"\x1b[0m") {
+		t.Errorf("epic item not colored:\n%q", b.String())
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.Contains(line, plain.Name) && strings.Contains(line, "\x1b[") {
+			t.Errorf("common item colored: %q", line)
+		}
+	}
+
+	b.Reset()
+	p.DisplayInventory(&b, "", false)
+	if strings.Contains(b.String(), "\x1b[") {
+		t.Errorf("colors shown with color off:\n%q", b.String())
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// StartRecording writes how the run starts to w - its seed, class, player
+// name, map file and opening screen - then copies every command the game
+// handles to it, one per line, so the run can be replayed exactly
+func (g *Game) StartRecording(w io.Writer) {
+	fmt.Fprintf(w, "seed %d %s\n", g.Seed, g.Class)
+	fmt.Fprintf(w, "@name %s\n", g.Name)
+	if g.MapFile != "" {
+		fmt.Fprintf(w, "@map %s\n", g.MapFile)
+	}
+	if g.State == StateMainMenu {
+		fmt.Fprintln(w, "@start menu")
+	}
+	g.Recorder = w
+}
+
+// recordingStart is how a recorded run started
+type recordingStart struct {
+	Seed    int64
+	Class   Class
+	Name    string // Player's name, or "" to keep the default
+	MapFile string // Map file the first level was loaded from, if any
+	Menu    bool   // Whether the run opened on the main menu
+}
+
+// Apply sets up a fresh game the way the recorded run started
+func (s recordingStart) Apply(g *Game) error {
+	if s.Name != "" {
+		g.SetName(s.Name)
+	}
+	g.ChooseClass(s.Class)
+	if s.MapFile != "" {
+		if err := g.StartOnMap(s.MapFile); err != nil {
+			return fmt.Errorf("could not load %s: %v", s.MapFile, err)
+		}
+	}
+	if s.Menu {
+		g.State = StateMainMenu
+	}
+	return nil
+}
+
+// readRecording reads a run saved with StartRecording, returning how it
+// started and its commands in order. Recordings made before classes existed
+// are played as warriors, and ones without "@" lines start in play.
+func readRecording(r io.Reader) (recordingStart, []string, error) {
+	var start recordingStart
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return start, nil, err
+		}
+		return start, nil, fmt.Errorf("the recording is empty")
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 2 || len(fields) > 3 || fields[0] != "seed" {
+		return start, nil, fmt.Errorf("the recording doesn't start with its seed")
+	}
+	seed, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return start, nil, fmt.Errorf("bad seed %q", fields[1])
+	}
+	start.Seed, start.Class = seed, ClassWarrior
+	if len(fields) == 3 {
+		var ok bool
+		if start.Class, ok = parseClass(fields[2]); !ok {
+			return start, nil, fmt.Errorf("unknown class %q", fields[2])
+		}
+	}
+
+	// The "@" lines after the seed describe the start; commands follow them
+	var commands []string
+	header := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header && strings.HasPrefix(line, "@") {
+			key, value, _ := strings.Cut(line[1:], " ")
+			switch key {
+			case "name":
+				start.Name = value
+			case "map":
+				start.MapFile = value
+			case "start":
+				start.Menu = value == "menu"
+			default:
+				return start, nil, fmt.Errorf("unknown recording line %q", line)
+			}
+			continue
+		}
+		header = false
+		commands = append(commands, line)
+	}
+	return start, commands, scanner.Err()
+}
+
+// Replay feeds recorded commands through Update in order, stopping when they
+// run out or the game is quit, then shows where the run ended up
+func (g *Game) Replay(commands []string) {
+	played := 0
+	for _, cmd := range commands {
+		if g.State == StateQuit {
+			break
+		}
+		g.Update(cmd)
+		played++
+	}
+
+	g.screen.Draw(g.Dungeon, g.Player)
+	g.screen.DrawStatus(g.Dungeon, g.Player, g.Turns)
+	g.Messages.PrintRecent(g.Out, 5)
+	fmt.Fprintf(g.Out, "\nReplay finished after %d of %d commands.\n", played, len(commands))
+}
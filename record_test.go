@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayMatchesRecordedRun(t *testing.T) {
+	var rec, out bytes.Buffer
+	in := NewInput(strings.NewReader("d\nd\n1\ns\ns\n.\nq y\n"), &out, false)
+	g := NewGameWithSeed(in, &out, DefaultConfig(), 7)
+	g.SetName("Ada")
+	g.ChooseClass(ClassRogue)
+	g.State = StateMainMenu
+	g.StartRecording(&rec)
+
+	done := make(chan struct{})
+	go func() {
+		g.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the recorded game was still running after its script ended")
+	}
+
+	start, commands, err := readRecording(&rec)
+	if err != nil {
+		t.Fatalf("reading the recording: %v", err)
+	}
+	replay := NewGameWithSeed(nil, io.Discard, DefaultConfig(), start.Seed)
+	if err := start.Apply(replay); err != nil {
+		t.Fatalf("setting up the replay: %v", err)
+	}
+	replay.Replay(commands)
+
+	if replay.Name != "Ada" || replay.Class != ClassRogue {
+		t.Errorf("replay played %s the %s, want Ada the %s", replay.Name, replay.Class, ClassRogue)
+	}
+	if replay.Turns != g.Turns {
+		t.Errorf("replay ended on turn %d, the run on turn %d", replay.Turns, g.Turns)
+	}
+	if replay.Player.X != g.Player.X || replay.Player.Y != g.Player.Y {
+		t.Errorf("replay ended at (%d,%d), the run at (%d,%d)", replay.Player.X, replay.Player.Y, g.Player.X, g.Player.Y)
+	}
+}
+
+func TestReadOldRecording(t *testing.T) {
+	start, commands, err := readRecording(strings.NewReader("seed 42\nd\n@\n"))
+	if err != nil {
+		t.Fatalf("reading the recording: %v", err)
+	}
+	if start.Seed != 42 || start.Class != ClassWarrior || start.Menu {
+		t.Errorf("start = %+v, want seed 42 as a warrior, in play", start)
+	}
+	if len(commands) != 2 || commands[0] != "d" || commands[1] != "@" {
+		t.Errorf("commands = %q, want [d @]", commands)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Renderer draws the dungeon to a terminal, remembering the previous frame so
+// that only the cells which changed since last turn are redrawn. Output that
+// isn't a terminal gets the plain full-grid printout instead.
+type Renderer struct {
+	out        io.Writer
+	fd         int
+	ansi       bool   // Whether cursor-positioned updates can be used
+	color      bool   // Whether to draw in color, when the terminal allows it
+	prev       []rune // The map as last drawn, or nil if the screen must be redrawn
+	header     string // The header line as last drawn
+	cols, rows int    // Terminal size when the last frame was drawn
+	lowHealth  bool   // Whether the last status line showed the low health warning
+}
+
+// NewRenderer creates a renderer writing to out
+func NewRenderer(out io.Writer) *Renderer {
+	r := &Renderer{out: out, fd: -1, color: true}
+	if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		r.fd = int(f.Fd())
+		r.ansi = true
+	}
+	return r
+}
+
+// Clears reports whether drawing a frame may clear the screen
+func (r *Renderer) Clears() bool {
+	return r.ansi
+}
+
+// SetColor turns colored output on or off, redrawing the screen to match
+func (r *Renderer) SetColor(on bool) {
+	r.color = on
+	r.Invalidate()
+}
+
+// Colored reports whether text printed alongside the map may be colored
+func (r *Renderer) Colored() bool {
+	return r.ansi && r.color
+}
+
+// Invalidate forces the next frame to redraw the whole screen, e.g. after
+// other text has been printed over or scrolled the map
+func (r *Renderer) Invalidate() {
+	r.prev = nil
+}
+
+// DrawStatus prints the player's status line, followed by where they are and
+// what they have equipped. At low health it adds a warning,
+// colors the status red, and rings the terminal bell when health first drops.
+func (r *Renderer) DrawStatus(d *Dungeon, p *Player, turns int) {
+	status := p.StatusLine(turns)
+	low := p.LowHealth()
+	if low && r.ansi && r.color {
+		status = "\x1b[31m" + status + "\x1b[0m"
+	}
+	fmt.Fprintln(r.out, status)
+	fmt.Fprintln(r.out, d.LocationLine(p)+" | "+p.EquipmentLine())
+	
+	if low {
+		warning := "*** LOW HEALTH ***"
+		if !r.lowHealth && r.ansi {
+			warning += "\a"
+		}
+		fmt.Fprintln(r.out, warning)
+	}
+	r.lowHealth = low
+}
+
+// Draw renders the dungeon as seen by the player and leaves the cursor on a
+// cleared line just below the map, ready for the status line and messages
+func (r *Renderer) Draw(d *Dungeon, p *Player) {
+	if !r.ansi {
+		d.Print(r.out, p)
+		return
+	}
+	
+	// Redraw everything on the first frame, a new level, or a resized terminal
+	cols, rows, _ := term.GetSize(r.fd)
+	header := fmt.Sprintf("Dungeon Level: %d (%s)", d.Level, d.Theme.Name)
+	full := r.prev == nil || len(r.prev) != d.Width*d.Height || cols != r.cols || rows != r.rows
+	
+	var b strings.Builder
+	if full {
+		b.WriteString("\x1b[2J")
+		r.prev = make([]rune, d.Width*d.Height)
+	}
+	if full || header != r.header {
+		fmt.Fprintf(&b, "\x1b[1;1H\x1b[K%s", header)
+	}
+	
+	// Only move the cursor when the next changed cell isn't right after the last one
+	last := -1
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			i := y*d.Width + x
+			ch := d.DisplayRune(x, y, p)
+			if !full && r.prev[i] == ch {
+				continue
+			}
+			if i != last+1 || x == 0 {
+				fmt.Fprintf(&b, "\x1b[%d;%dH", y+2, x+1)
+			}
+			if code := cellColor(d, p, x, y, ch); code != "" && r.color {
+				fmt.Fprintf(&b, "%s%c\x1b[0m", code, ch)
+			} else {
+				b.WriteRune(ch)
+			}
+			r.prev[i] = ch
+			last = i
+		}
+	}
+	
+	// Park the cursor below the map and clear last turn's status and messages
+	fmt.Fprintf(&b, "\x1b[%d;1H\x1b[J", d.Height+2)
+	io.WriteString(r.out, b.String())
+	r.header, r.cols, r.rows = header, cols, rows
+}
+
+// cellColor returns the ANSI color a map cell showing ch is drawn in, or ""
+// to leave it plain. Flames are red and visible items take their rarity's color.
+func cellColor(d *Dungeon, p *Player, x, y int, ch rune) string {
+	if ch == fireSymbol && d.Burning(x, y) {
+		return "\x1b[31m"
+	}
+	if !d.Revealed && !d.IsLit(x, y, p) {
+		return ""
+	}
+	if item := d.GetItemAt(x, y); item != nil && ch == item.Symbol && d.GetEnemyAt(x, y) == nil {
+		return rarityColor(item.Rarity)
+	}
+	return ""
+}
+
+// rarityColor returns the ANSI color items of the given rarity are shown in,
+// matching the colors the full-screen interface uses
+func rarityColor(rarity Rarity) string {
+	switch rarity {
+	case Uncommon:
+		return "\x1b[32m"
+	case Rare:
+		return "\x1b[34m"
+	case Epic:
+		return "\x1b[35m"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCellColorShowsItemRarity(t *testing.T) {
+	d := NewDungeon(1, rand.New(rand.NewSource(1)), DefaultConfig())
+	d.clearEnemies()
+	d.Revealed = true
+	p := NewPlayer(0, 0, d.Config, ClassWarrior)
+
+	room := d.Rooms[len(d.Rooms)-1]
+	x, y := room.X, room.Y
+	d.Items, d.itemAt = nil, make(map[[2]int]int)
+	d.AddItem(NewWeapon(x, y, "Sword", 5, Rare))
+
+	if got := cellColor(d, p, x, y, d.DisplayRune(x, y, p)); got != "\x1b[34m" {
+		t.Errorf("rare item drawn with %q, want blue", got)
+	}
+	if got := cellColor(d, p, x+1, y, d.DisplayRune(x+1, y, p)); got != "" {
+		t.Errorf("bare floor drawn with %q, want no color", got)
+	}
+}
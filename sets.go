@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// armorSets are the names of the matching armor sets found in the dungeon
+var armorSets = []string{"Warden's", "Nightstalker's", "Dragonscale"}
+
+// Bonuses for wearing several pieces of the same set
+const (
+	setDefenseBonus = 2 // Extra defense for two or more pieces
+	setRegenBonus   = 2 // Faster regeneration for a full set of three
+)
+
+// tagSet makes a piece of armor part of the named set
+func tagSet(item *Item, set string) {
+	item.SetName = set
+	item.Name = set + " " + item.Name
+}
+
+// setCounts returns how many pieces of each set the player is wearing
+func (p *Player) setCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, item := range p.Armor {
+		if item.SetName != "" {
+			counts[item.SetName]++
+		}
+	}
+	return counts
+}
+
+// SetBonusLines describes every set bonus currently active
+func (p *Player) SetBonusLines() []string {
+	counts := p.setCounts()
+	sets := make([]string, 0, len(counts))
+	for set, n := range counts {
+		if n >= 2 {
+			sets = append(sets, set)
+		}
+	}
+	sort.Strings(sets)
+
+	lines := make([]string, 0, len(sets))
+	for _, set := range sets {
+		line := fmt.Sprintf("%s set (%d pieces): +%d defense", set, counts[set], setDefenseBonus)
+		if counts[set] >= 3 {
+			line += fmt.Sprintf(", +%d regeneration", setRegenBonus)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// difficulties are the difficulty settings, easiest first
+var difficulties = []string{"easy", "normal", "hard"}
+
+// settingNames are the settings screen's toggles, in the order they are numbered
+var settingNames = []string{"Color", "Auto-pickup", "Descend confirmation", "Difficulty"}
+
+// settingValues describes the current value of each setting, in the order of settingNames
+func (g *Game) settingValues() []string {
+	cfg := g.Config
+	return []string{
+		onOff(cfg.Color),
+		onOff(cfg.AutoPickup),
+		onOff(cfg.DescendWarnRange > 0),
+		cfg.Difficulty + " (from the next game)",
+	}
+}
+
+// SettingsLines returns the settings screen as numbered lines
+func (g *Game) SettingsLines() []string {
+	values := g.settingValues()
+	lines := []string{"=== Settings ==="}
+	for i, name := range settingNames {
+		lines = append(lines, fmt.Sprintf("%d. %s: %s", i+1, name, values[i]))
+	}
+	return lines
+}
+
+// onOff describes a toggle's state
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// updateSettings changes the setting picked by number and saves the
+// settings if the game has somewhere to keep them, or goes back to the main menu on anything else
+func (g *Game) updateSettings(cmd string) {
+	n, err := strconv.Atoi(strings.TrimSpace(cmd))
+	if err != nil || n < 1 || n > len(settingNames) {
+		g.State = StateMainMenu
+		return
+	}
+
+	cfg := g.Config
+	switch n {
+	case 1:
+		cfg.Color = !cfg.Color
+		g.screen.SetColor(cfg.Color)
+	case 2:
+		cfg.AutoPickup = !cfg.AutoPickup
+		g.Player.AutoPickup = cfg.AutoPickup
+	case 3:
+		// Turning it off negates the range, so turning it back on restores it
+		if cfg.DescendWarnRange == 0 {
+			cfg.DescendWarnRange = DefaultConfig().DescendWarnRange
+		} else {
+			cfg.DescendWarnRange = -cfg.DescendWarnRange
+		}
+	case 4:
+		cfg.Difficulty = nextDifficulty(cfg.Difficulty)
+	}
+	g.Messages.Log("%s is now %s.", settingNames[n-1], g.settingValues()[n-1])
+
+	if g.SettingsFile == "" {
+		return
+	}
+	if err := SaveConfig(g.SettingsFile, cfg); err != nil {
+		g.Messages.Log("Could not save the settings: %v", err)
+	}
+}
+
+// nextDifficulty returns the difficulty after the given one, wrapping around
+func nextDifficulty(difficulty string) string {
+	for i, name := range difficulties {
+		if name == difficulty {
+			return difficulties[(i+1)%len(difficulties)]
+		}
+	}
+	return "normal"
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDescendConfirmationKeepsCustomRange(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DescendWarnRange = 7
+	g := NewGameWithSeed(nil, io.Discard, cfg, 1)
+	g.State = StateSettings
+
+	g.Update("3")
+	if cfg.DescendWarnRange > 0 {
+		t.Fatalf("range = %d after turning confirmation off, want it disabled", cfg.DescendWarnRange)
+	}
+	g.Update("3")
+	if cfg.DescendWarnRange != 7 {
+		t.Errorf("range = %d after turning confirmation back on, want 7", cfg.DescendWarnRange)
+	}
+}
+
+func TestDifficultyChangeWaitsForNextRun(t *testing.T) {
+	cfg := DefaultConfig()
+	g := NewGameWithSeed(nil, io.Discard, cfg, 1)
+	g.State = StateSettings
+	g.Update("4")
+	if cfg.Difficulty != "hard" {
+		t.Fatalf("difficulty = %q after one change, want hard", cfg.Difficulty)
+	}
+
+	g.Descend()
+	if got := g.Dungeon.Config.Difficulty; got != "normal" {
+		t.Errorf("level %d was made at %q difficulty mid-run, want normal", g.Dungeon.Level, got)
+	}
+	g.restart()
+	if got := g.Dungeon.Config.Difficulty; got != "hard" {
+		t.Errorf("the next run is at %q difficulty, want hard", got)
+	}
+}
+
+func TestSettingsSavedOnlyWithSettingsFile(t *testing.T) {
+	dir := inTempDir(t)
+	g := NewGameWithSeed(nil, io.Discard, DefaultConfig(), 1)
+	g.State = StateSettings
+	g.Update("2")
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+		t.Errorf("changing a setting without a settings file wrote %s", configFile)
+	}
+
+	g.SettingsFile = filepath.Join(dir, "settings.json")
+	g.Update("2")
+	saved, err := LoadConfig(g.SettingsFile)
+	if err != nil {
+		t.Fatalf("loading the saved settings: %v", err)
+	}
+	if saved.AutoPickup != g.Config.AutoPickup {
+		t.Errorf("saved auto-pickup = %v, want %v", saved.AutoPickup, g.Config.AutoPickup)
+	}
+}
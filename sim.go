@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// maxSimTurns ends a simulated game that is taking too long, such as a bot
+// stuck walking back and forth
+const maxSimTurns = 5000
+
+// SimResult sums up how a batch of simulated games went
+type SimResult struct {
+	Games    int
+	Seed     int64 // Seed of the first game; the others follow on from it
+	Won      int
+	Died     int
+	TimedOut int // Games stopped after maxSimTurns
+	Depth    int // Total depth reached across games
+	Deepest  int // Deepest level any game reached
+	Gold     int
+	Turns    int
+	Kills    int
+}
+
+// Simulate plays n games to completion with the built-in bot, without
+// drawing anything, starting from seed and using the next seed for each game
+func Simulate(n int, seed int64, cfg *Config) SimResult {
+	result := SimResult{Games: n, Seed: seed}
+	for i := 0; i < n; i++ {
+		g := NewGameWithSeed(nil, io.Discard, cfg, seed+int64(i))
+		g.playBot()
+
+		switch g.State {
+		case StateVictory:
+			result.Won++
+		case StateGameOver:
+			result.Died++
+		default:
+			result.TimedOut++
+		}
+		result.Depth += g.Deepest
+		result.Deepest = max(result.Deepest, g.Deepest)
+		result.Gold += g.Player.Gold
+		result.Turns += g.Turns
+		result.Kills += g.Player.Stats.TotalKills()
+	}
+	return result
+}
+
+// Print writes the averages of the simulated games to w
+func (r SimResult) Print(w io.Writer) {
+	if r.Games == 0 {
+		return
+	}
+	games := float64(r.Games)
+	fmt.Fprintf(w, "Simulated %d games from seed %d:\n", r.Games, r.Seed)
+	fmt.Fprintf(w, "  Won: %d (%.1f%%) | Died: %d | Out of time: %d\n", r.Won, 100*float64(r.Won)/games, r.Died, r.TimedOut)
+	fmt.Fprintf(w, "  Average depth: %.2f (deepest %d)\n", float64(r.Depth)/games, r.Deepest)
+	fmt.Fprintf(w, "  Average gold: %.1f\n", float64(r.Gold)/games)
+	fmt.Fprintf(w, "  Average turns: %.1f\n", float64(r.Turns)/games)
+	fmt.Fprintf(w, "  Average kills: %.1f\n", float64(r.Kills)/games)
+}
+
+// playBot feeds the bot's commands through Update until the game ends
+func (g *Game) playBot() {
+	stuck := false
+	for g.State == StatePlaying && g.Turns < maxSimTurns {
+		cmd := "wait"
+		if !stuck {
+			cmd = botCommand(g)
+		}
+
+		// Wait instead if the command achieved nothing, such as walking into a wall
+		turns, level := g.Turns, g.Dungeon.Level
+		g.Update(cmd)
+		stuck = g.Turns == turns && g.Dungeon.Level == level
+	}
+}
+
+// botCommand picks the bot's next move: heal or eat when it needs to, fight
+// what's next to it unless badly hurt, rest when nothing is around, explore
+// every room, then take the stairs
+func botCommand(g *Game) string {
+	p, d := g.Player, g.Dungeon
+	enemy, dist := d.NearestEnemy(p.X, p.Y)
+	hurt := p.Health*3 < p.MaxHealth
+
+	if hurt && botHasPotion(p) {
+		return "quickheal"
+	}
+	if food := botFood(p); food > 0 && p.Hunger < MaxHunger/5 {
+		return fmt.Sprintf("use %d", food)
+	}
+	if enemy != nil && dist == 1 {
+		if hurt {
+			return "flee"
+		}
+		return stepCommand(toward(p.X, p.Y, enemy.X, enemy.Y))
+	}
+	if p.Health*2 < p.MaxHealth && d.VisibleEnemy(p) == nil {
+		return "rest full"
+	}
+	if d.GetTileAt(p.X, p.Y) == StairsDown && botExplored(d, p) {
+		return "stairs y"
+	}
+
+	// Head for the nearest room not yet visited, then the stairs
+	for _, target := range botTargets(d, p) {
+		if path := d.FindPath(p.X, p.Y, target[0], target[1]); len(path) > 0 {
+			if dir, ok := facing(path[0][0]-p.X, path[0][1]-p.Y); ok {
+				return stepCommand(dir)
+			}
+		}
+	}
+	return "wait"
+}
+
+// botHasPotion reports whether the player knows which of their potions heals
+func botHasPotion(p *Player) bool {
+	for _, item := range p.Inventory {
+		if item.Type == ItemPotion && p.Identities.Identified(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// botFood returns the inventory number of some food, or 0 if there is none
+func botFood(p *Player) int {
+	for i, item := range p.Inventory {
+		if item.Type == ItemFood {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// botExplored reports whether the bot has nothing left to explore on the level
+func botExplored(d *Dungeon, p *Player) bool {
+	return len(botTargets(d, p)) <= 1
+}
+
+// botTargets lists the centres of the unvisited rooms, nearest first,
+// followed by the stairs
+func botTargets(d *Dungeon, p *Player) [][2]int {
+	var targets [][2]int
+	for i := range d.Rooms {
+		room := &d.Rooms[i]
+		if d.Visited[room] {
+			continue
+		}
+		x, y := room.X+room.Width/2, room.Y+room.Height/2
+		if d.FindPath(p.X, p.Y, x, y) != nil {
+			targets = append(targets, [2]int{x, y})
+		}
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return distance(p.X, p.Y, targets[i][0], targets[i][1]) < distance(p.X, p.Y, targets[j][0], targets[j][1])
+	})
+	if x, y, ok := d.StairsPos(); ok {
+		targets = append(targets, [2]int{x, y})
+	}
+	return targets
+}
@@ -0,0 +1,102 @@
+package main
+
+// SpellType represents the different spells the player can cast
+type SpellType int
+
+const (
+	SpellMagicMissile SpellType = iota
+	SpellHeal
+	SpellBlink
+)
+
+// Spell describes a castable spell
+type Spell struct {
+	Name        string // Name of the spell
+	Description string // Description of the effect
+	Cost        int    // Mana cost to cast
+	Targeted    bool   // Whether the spell needs a direction
+}
+
+// spells holds the definition of every spell, indexed by SpellType
+var spells = map[SpellType]Spell{
+	SpellMagicMissile: {"Magic Missile", "Deals 5 damage to the first enemy in a line", 3, true},
+	SpellHeal:         {"Heal", "Restores 8 health points", 5, false},
+	SpellBlink:        {"Blink", "Teleports you up to 4 tiles in a direction", 4, true},
+}
+
+// KnowsSpell checks whether the player has learned the given spell
+func (p *Player) KnowsSpell(spell SpellType) bool {
+	for _, known := range p.Spells {
+		if known == spell {
+			return true
+		}
+	}
+	return false
+}
+
+// CastSpell casts a known spell, returning true if the spell was cast.
+// dir only matters for targeted spells.
+func (p *Player) CastSpell(spell SpellType, dir Direction, d *Dungeon) bool {
+	info := spells[spell]
+	dx, dy := dir.Delta()
+
+	// Check if the player has enough mana
+	if p.Mana < info.Cost {
+		p.Messages.Log("You don't have enough mana to cast %s.", info.Name)
+		return false
+	}
+	p.Mana -= info.Cost
+
+	switch spell {
+	case SpellMagicMissile:
+		// Fire a bolt along the line, hitting the first enemy
+		path := line(p.X, p.Y, p.X+dx*8, p.Y+dy*8)
+		for _, pos := range path[1:] {
+			x, y := pos[0], pos[1]
+			if !d.IsWalkable(x, y) {
+				break
+			}
+			if enemy := d.GetEnemyAt(x, y); enemy != nil {
+				damage := p.DealDamage(enemy, 5, Physical, d)
+				p.Messages.Log("Your magic missile strikes %s for %d damage!", enemy.Title(), damage)
+				if enemy.Health <= 0 {
+					p.DefeatEnemy(enemy, d)
+				}
+				return true
+			}
+		}
+		p.Messages.Log("Your magic missile fizzles out.")
+
+	case SpellHeal:
+		// Restore health
+		p.Health += 8
+		if p.Health > p.MaxHealth {
+			p.Health = p.MaxHealth
+		}
+		p.Messages.Log("A warm light washes over you. You heal 8 health points.")
+
+	case SpellBlink:
+		// Teleport to the farthest free tile along the line
+		destX, destY := p.X, p.Y
+		path := line(p.X, p.Y, p.X+dx*4, p.Y+dy*4)
+		for _, pos := range path[1:] {
+			x, y := pos[0], pos[1]
+			if !d.IsWalkable(x, y) {
+				break
+			}
+			if d.GetEnemyAt(x, y) == nil {
+				destX, destY = x, y
+			}
+		}
+
+		if destX == p.X && destY == p.Y {
+			p.Messages.Log("You blink in place.")
+			return true
+		}
+		p.X, p.Y = destX, destY
+		p.Messages.Log("You blink across the room!")
+		p.CheckPosition(d)
+	}
+
+	return true
+}
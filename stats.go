@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stats records what the player has done during a run
+type Stats struct {
+	Kills          map[string]int // Enemies defeated, by type
+	ItemsCollected int            // Items and gold picked up
+	TrapsTriggered int            // Traps the player set off
+	DamageDealt    int            // Total damage done to enemies
+	DamageTaken    int            // Total damage suffered
+}
+
+// TotalKills returns the number of enemies defeated of any type
+func (s Stats) TotalKills() int {
+	total := 0
+	for _, n := range s.Kills {
+		total += n
+	}
+	return total
+}
+
+// Summary describes the run as lines of text for the end-game screens
+func (s Stats) Summary() []string {
+	// List kills by type in a stable order
+	names := make([]string, 0, len(s.Kills))
+	for name := range s.Kills {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	kills := make([]string, 0, len(names))
+	for _, name := range names {
+		kills = append(kills, fmt.Sprintf("%d %s", s.Kills[name], name))
+	}
+	
+	lines := []string{fmt.Sprintf("Enemies defeated: %d", s.TotalKills())}
+	if len(kills) > 0 {
+		lines[0] += " (" + strings.Join(kills, ", ") + ")"
+	}
+	return append(lines,
+		fmt.Sprintf("Items collected: %d", s.ItemsCollected),
+		fmt.Sprintf("Traps triggered: %d", s.TrapsTriggered),
+		fmt.Sprintf("Damage dealt: %d | Damage taken: %d", s.DamageDealt, s.DamageTaken),
+	)
+}
@@ -0,0 +1,39 @@
+package main
+
+// sneakAttackBonus is the extra damage, as a percentage, of a blow against
+// an enemy that hasn't noticed the player
+const sneakAttackBonus = 100
+
+// ToggleSneak starts or stops the player sneaking
+func (p *Player) ToggleSneak() {
+	p.Sneaking = !p.Sneaking
+	if p.Sneaking && p.sneaksFreely() {
+		p.Messages.Log("You start sneaking. Enemies notice you only half as far away.")
+	} else if p.Sneaking {
+		p.Messages.Log("You start sneaking. Enemies notice you only half as far away, but you move at half speed.")
+	} else {
+		p.Messages.Log("You stop sneaking.")
+	}
+}
+
+// BreakStealth ends any sneaking once the player is noticed, e.g. by fighting
+func (p *Player) BreakStealth() {
+	if p.Sneaking {
+		p.Sneaking = false
+		p.Messages.Log("You can no longer stay hidden.")
+	}
+}
+
+// notices reports whether the enemy picks up on the player within radius
+// tiles, a range sneaking halves, or is hunting them anyway. An enemy that
+// notices the player stays aware of them from then on.
+func (e *Enemy) notices(p *Player, radius int) bool {
+	if p.Sneaking {
+		radius /= 2
+	}
+	if e.Alerted || distance(e.X, e.Y, p.X, p.Y) < radius {
+		e.Aware = true
+		return true
+	}
+	return false
+}
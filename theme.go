@@ -0,0 +1,68 @@
+package main
+
+import "math/rand"
+
+// Theme describes the look and inhabitants of a dungeon level
+type Theme struct {
+	Name        string       // Name announced when entering the level
+	WallSymbol  rune         // Symbol used to draw walls
+	FloorSymbol rune         // Symbol used to draw floors
+	Enemies     []spawnEntry // Enemy types that spawn here and how often
+	MinTraps    int          // Minimum number of traps generated
+	MaxTraps    int          // Maximum number of traps generated
+}
+
+// spawnEntry is an enemy type in a theme's spawn table. Its weight shifts by
+// PerLevel for every level below the first, so weak enemies can thin out
+// and tough ones grow common as the player descends.
+type spawnEntry struct {
+	Name     string // Name of the enemy type
+	Weight   int    // Relative chance of spawning on the first level
+	PerLevel int    // Change in weight per level deeper
+}
+
+// Dungeon themes, cycled through as the player descends
+var themes = []Theme{
+	{
+		Name:        "Caves",
+		WallSymbol:  '#',
+		FloorSymbol: '.',
+		Enemies:     []spawnEntry{{"Goblin", 6, -1}, {"Rat", 6, -1}, {"Orc", 3, 1}, {"Mold", 2, 0}, {"Thief", 2, 0}, {"Bat", 2, 0}},
+		MinTraps:    2,
+		MaxTraps:    5,
+	},
+	{
+		Name:        "Sewers",
+		WallSymbol:  '=',
+		FloorSymbol: ',',
+		Enemies:     []spawnEntry{{"Rat", 8, -1}, {"Goblin", 4, -1}, {"Troll", 1, 1}, {"Mold", 2, 0}, {"Thief", 2, 0}, {"Bat", 3, 0}},
+		MinTraps:    1,
+		MaxTraps:    3,
+	},
+	{
+		Name:        "Crypt",
+		WallSymbol:  '#',
+		FloorSymbol: '_',
+		Enemies:     []spawnEntry{{"Skeleton", 4, 1}, {"Troll", 2, 1}, {"Orc", 4, -1}, {"Ghost", 2, 0}},
+		MinTraps:    4,
+		MaxTraps:    7,
+	},
+}
+
+// themeForLevel picks the theme for the given dungeon level, changing every two levels
+func themeForLevel(level int) Theme {
+	if level < 1 {
+		level = 1
+	}
+	return themes[((level-1)/2)%len(themes)]
+}
+
+// pickEnemy chooses the name of an enemy type to spawn at the given depth.
+// Every entry keeps at least a small chance, however deep the level.
+func (t Theme) pickEnemy(rng *rand.Rand, depth int) string {
+	weights := make([]int, len(t.Enemies))
+	for i, entry := range t.Enemies {
+		weights[i] = max(entry.Weight+entry.PerLevel*(depth-1), 1)
+	}
+	return t.Enemies[weightedPick(rng, weights)].Name
+}
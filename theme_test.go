@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// averageHealth returns the mean base health of n enemies the theme picks
+// at the given depth
+func averageHealth(theme Theme, depth, n int) float64 {
+	rng := rand.New(rand.NewSource(1))
+	total := 0
+	for i := 0; i < n; i++ {
+		total += enemyTypes[theme.pickEnemy(rng, depth)].health
+	}
+	return float64(total) / float64(n)
+}
+
+func TestDeepLevelsSpawnTougherEnemies(t *testing.T) {
+	// Within each theme, going deeper shifts the odds to the tougher enemies
+	for _, theme := range themes {
+		shallow, deep := averageHealth(theme, 1, 5000), averageHealth(theme, 8, 5000)
+		if deep <= shallow {
+			t.Errorf("%s: enemies average %.2f health at depth 8, no more than the %.2f at depth 1", theme.Name, deep, shallow)
+		}
+	}
+
+	// And the levels as generated, across themes, get tougher too
+	cfg := DefaultConfig()
+	levelHealth := func(level int) float64 {
+		total, count := 0, 0
+		for seed := int64(1); seed <= 200; seed++ {
+			d := NewDungeon(level, rand.New(rand.NewSource(seed)), cfg)
+			for _, enemy := range d.Enemies {
+				if enemy.Hostile {
+					total += enemy.MaxHealth
+					count++
+				}
+			}
+		}
+		return float64(total) / float64(count)
+	}
+	if shallow, deep := levelHealth(1), levelHealth(5); deep <= shallow {
+		t.Errorf("level 5 enemies average %.2f health, no more than the %.2f on level 1", deep, shallow)
+	}
+}
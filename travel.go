@@ -0,0 +1,75 @@
+package main
+
+// maxTravelSteps caps how far a single "travel" walks
+const maxTravelSteps = 500
+
+// travelToStairs walks the player to the stairs down along the shortest
+// path through explored tiles, one step per turn, once they have been found. Like resting and
+// repeating, it stops as soon as an enemy comes into view or the player is hurt.
+func (g *Game) travelToStairs() {
+	player := g.Player
+	x, y, ok := g.Dungeon.StairsPos()
+	if !ok || !g.Dungeon.Explored[y][x] {
+		g.Messages.Log("You haven't found the stairs down yet.")
+		return
+	}
+	if player.X == x && player.Y == y {
+		g.Messages.Log("You are already on the stairs.")
+		return
+	}
+
+	for i := 0; i < maxTravelSteps && g.State == StatePlaying; i++ {
+		d := g.Dungeon
+		if enemy := d.VisibleEnemy(player); enemy != nil && i == 0 {
+			g.Messages.Log("You can't travel with a %s in sight.", enemy.Name)
+			return
+		} else if enemy != nil {
+			g.Messages.Log("You stop: you see a %s.", enemy.Name)
+			return
+		}
+
+		// Find the way afresh each step, since doors open and monsters move
+		path := d.FindPathAvoiding(player.X, player.Y, x, y, d.avoidedByTravel)
+		if path == nil {
+			g.Messages.Log("You know of no safe way to the stairs from here.")
+			return
+		}
+		next := path[0]
+		if enemy := d.GetEnemyAt(next[0], next[1]); enemy != nil && enemy.Hostile {
+			g.Messages.Log("You stop: something is in the way.")
+			return
+		}
+		dir, _ := facing(next[0]-player.X, next[1]-player.Y)
+
+		health, level := player.Health, d.Level
+		g.updatePlaying(stepCommand(dir))
+		if g.Dungeon.Level != level || g.State != StatePlaying {
+			return
+		}
+		if player.X != next[0] || player.Y != next[1] {
+			g.Messages.Log("You stop: something is in the way.")
+			return
+		}
+		if player.X == x && player.Y == y {
+			g.Messages.Log("You arrive at the stairs down.")
+			return
+		}
+		if player.Health < health {
+			g.Messages.Log("You stop: something is hurting you!")
+			return
+		}
+	}
+}
+
+// avoidedByTravel reports whether traveling should steer around (x, y): a
+// tile the player hasn't explored, a trap they have spotted, or a teleporter
+// that would whisk them away
+func (d *Dungeon) avoidedByTravel(x, y int) bool {
+	if !d.Explored[y][x] {
+		return true
+	}
+	if trap := d.GetTrapAt(x, y); trap != nil && !trap.Hidden {
+		return true
+	}
+	return d.GetTileAt(x, y) == Teleporter
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+// travelGame returns a game on seed 1 with the first level cleared of
+// enemies and traps, so nothing interrupts traveling
+func travelGame(cfg *Config) *Game {
+	g := NewGameWithSeed(nil, io.Discard, cfg, 1)
+	d := g.Dungeon
+	d.clearEnemies()
+	d.Traps = make(map[[2]int]*TrapState)
+	return g
+}
+
+// exploreAll marks every tile of the level as explored, or none of them
+func exploreAll(d *Dungeon, explored bool) {
+	for y := range d.Explored {
+		for x := range d.Explored[y] {
+			d.Explored[y][x] = explored
+		}
+	}
+}
+
+func TestTravelKeepsToExploredTiles(t *testing.T) {
+	g := travelGame(DefaultConfig())
+	d, p := g.Dungeon, g.Player
+	x, y, ok := d.StairsPos()
+	if !ok {
+		t.Fatal("the level has no stairs")
+	}
+
+	// Knowing only where the stairs are isn't enough to walk there
+	exploreAll(d, false)
+	d.Explored[y][x] = true
+	d.Explored[p.Y][p.X] = true
+	startX, startY := p.X, p.Y
+	g.Update("travel")
+	if p.X != startX || p.Y != startY {
+		t.Errorf("traveled through unexplored tiles from (%d,%d) to (%d,%d)", startX, startY, p.X, p.Y)
+	}
+
+	exploreAll(d, true)
+	g.Update("travel")
+	if p.X != x || p.Y != y {
+		t.Errorf("traveling over the explored level ended at (%d,%d), want the stairs at (%d,%d)", p.X, p.Y, x, y)
+	}
+}
+
+func TestDyingMidTravelRecordsDailyRunOnce(t *testing.T) {
+	inTempDir(t)
+	cfg := DefaultConfig()
+	cfg.TrapDamageMin, cfg.TrapDamageMax = 1000, 1000
+	g := travelGame(cfg)
+	g.Daily = "2026-01-01"
+	d, p := g.Dungeon, g.Player
+	exploreAll(d, true)
+
+	// Hide a deadly trap on the first step towards the stairs
+	x, y, _ := d.StairsPos()
+	path := d.FindPath(p.X, p.Y, x, y)
+	if len(path) < 2 {
+		t.Fatalf("the stairs are only %d steps away", len(path))
+	}
+	step := path[0]
+	d.Traps[step] = &TrapState{Kind: TrapSpikes, Hidden: true}
+
+	g.Update("travel")
+	if g.State != StateGameOver {
+		t.Fatalf("state = %d after traveling onto a deadly trap, want StateGameOver", g.State)
+	}
+	board, err := loadDailyBoard()
+	if err != nil {
+		t.Fatalf("loading the daily board: %v", err)
+	}
+	if runs := len(board[g.Daily]); runs != 1 {
+		t.Errorf("the run was recorded %d times, want once", runs)
+	}
+}
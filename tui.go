@@ -0,0 +1,507 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// tuiPrompt tracks what the full-screen interface is waiting for
+type tuiPrompt int
+
+const (
+	promptNone tuiPrompt = iota
+	promptLook
+	promptCastSpell
+	promptCastDirection
+	promptDescend
+	promptZapWand
+	promptZapDirection
+	promptLegend
+	promptQuit
+	promptDisarm
+	promptThrowItem
+	promptThrowDirection
+	promptDropItem
+	promptPager
+)
+
+// tuiPager pages through text too long for the prompt area, such as the
+// help or the message history, a screenful at a time
+type tuiPager struct {
+	lines []string
+	top   int // Index of the first line on screen
+}
+
+// pagerRows is how many lines of text fit on a page, leaving a row for the hint
+func pagerRows(screen tcell.Screen) int {
+	_, rows := screen.Size()
+	return max(rows-1, 1)
+}
+
+// runTUI plays the game in a full-screen terminal interface that reads
+// single keypresses and redraws the whole screen after every action
+func runTUI(g *Game) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	defer screen.Fini()
+	
+	prompt := promptNone
+	var spellKey, wandKey, itemKey string
+	var pager tuiPager
+	
+	for g.State != StateQuit {
+		drawTUI(screen, g, prompt, pager)
+		
+		ev, ok := screen.PollEvent().(*tcell.EventKey)
+		if !ok {
+			continue
+		}
+		key := tuiKey(ev)
+		
+		switch g.State {
+		case StateMainMenu:
+			// Choose by number; escape leaves the game
+			if key == "esc" {
+				g.State = StateQuit
+			} else {
+				g.Update(key)
+			}
+			
+		case StateSettings:
+			// Change a setting by number; any other key goes back
+			g.Update(key)
+			
+		case StateGameOver, StateVictory:
+			// Finished games can only restart or quit
+			if key == "esc" {
+				g.State = StateQuit
+			} else {
+				g.Update(key)
+			}
+			
+		case StateInventory:
+			// Use, throw, or drop an item by number, sort or filter the list, or leave the inventory
+			var index int
+			_, err := fmt.Sscanf(key, "%d", &index)
+			isItem := err == nil && index > 0 && index <= len(g.Player.Inventory)
+			if prompt == promptDescend {
+				// Confirm reading a Scroll of Descent with enemies around
+				if key == "y" {
+					g.Update(itemKey + " y")
+				}
+				prompt = promptNone
+			} else if prompt == promptThrowItem {
+				// Pick what to throw, then where
+				prompt = promptNone
+				if isItem {
+					itemKey = key
+					prompt = promptThrowDirection
+				}
+			} else if prompt == promptThrowDirection {
+				if _, ok := parseDirection(key); ok {
+					g.Update("t " + itemKey + " " + key)
+				}
+				prompt = promptNone
+			} else if prompt == promptDropItem {
+				if isItem {
+					g.Update("d " + key)
+				}
+				prompt = promptNone
+			} else if isItem {
+				if g.needsUseConfirm(index - 1) {
+					itemKey = key
+					prompt = promptDescend
+					break
+				}
+				g.Update(key)
+			} else if key == "o" {
+				g.Update("sort")
+			} else if key == "f" {
+				g.Update("f " + nextFilter(g.InventoryFilter))
+			} else if key == "t" {
+				prompt = promptThrowItem
+			} else if key == "d" {
+				prompt = promptDropItem
+			} else {
+				g.Update("b")
+			}
+			
+		default:
+			// Escape backs out of any prompt to the main menu
+			if key == "esc" {
+				prompt = promptNone
+				g.Update("menu")
+				continue
+			}
+			switch prompt {
+			case promptLook:
+				// Examine an adjacent tile (does not use a turn)
+				if _, ok := parseDirection(key); ok {
+					g.Update("x " + key)
+				}
+				prompt = promptNone
+				
+			case promptCastSpell:
+				// Pick a spell by number
+				prompt = promptNone
+				var index int
+				if _, err := fmt.Sscanf(key, "%d", &index); err != nil || index < 1 || index > len(g.Player.Spells) {
+					break
+				}
+				if spells[g.Player.Spells[index-1]].Targeted {
+					spellKey = key
+					prompt = promptCastDirection
+					break
+				}
+				g.Update("c " + key)
+				
+			case promptCastDirection:
+				// Aim the chosen spell
+				if _, ok := parseDirection(key); ok {
+					g.Update("c " + spellKey + " " + key)
+				}
+				prompt = promptNone
+				
+			case promptZapWand:
+				// Pick a wand by inventory number
+				prompt = promptNone
+				var index int
+				if _, err := fmt.Sscanf(key, "%d", &index); err != nil || index < 1 || index > len(g.Player.Inventory) {
+					break
+				}
+				wandKey = key
+				prompt = promptZapDirection
+				
+			case promptZapDirection:
+				// Aim the chosen wand
+				if _, ok := parseDirection(key); ok {
+					g.Update("zap " + wandKey + " " + key)
+				}
+				prompt = promptNone
+				
+			case promptQuit:
+				// Confirm abandoning the run
+				g.Update("q " + key)
+				prompt = promptNone
+				
+			case promptLegend:
+				// Any key closes the legend
+				prompt = promptNone
+				
+			case promptPager:
+				// Any key turns the page, closing the text after the last one
+				pager.top += pagerRows(screen)
+				if pager.top >= len(pager.lines) {
+					prompt = promptNone
+				}
+				
+			case promptDisarm:
+				// Disarm a trap next to the player
+				if _, ok := parseDirection(key); ok {
+					g.Update("disarm " + key)
+				}
+				prompt = promptNone
+				
+			case promptDescend:
+				// Confirm leaving the level with enemies around
+				g.Update("> " + key)
+				prompt = promptNone
+				
+			default:
+				switch commandFor(key) {
+				case "look":
+					prompt = promptLook
+				case "cast":
+					prompt = promptCastSpell
+				case "zap":
+					prompt = promptZapWand
+				case "legend":
+					prompt = promptLegend
+				case "disarm":
+					prompt = promptDisarm
+				case "help":
+					pager, prompt = tuiPager{lines: helpLines()}, promptPager
+				case "messages":
+					pager, prompt = tuiPager{lines: messageLines(g.Messages)}, promptPager
+				case "quit":
+					prompt = promptQuit
+				case "descend":
+					if g.needsDescendConfirm() {
+						prompt = promptDescend
+					} else {
+						g.Update(key)
+					}
+				case "":
+				default:
+					g.Update(key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// tuiKey converts a key event into the same command strings the line interface uses
+func tuiKey(ev *tcell.EventKey) string {
+	switch ev.Key() {
+	case tcell.KeyUp:
+		return "up"
+	case tcell.KeyDown:
+		return "down"
+	case tcell.KeyLeft:
+		return "left"
+	case tcell.KeyRight:
+		return "right"
+	case tcell.KeyEscape:
+		return "esc"
+	case tcell.KeyRune:
+		return string(ev.Rune())
+	}
+	return ""
+}
+
+// helpLines returns the instructions as lines of text
+func helpLines() []string {
+	var b strings.Builder
+	printHelp(&b)
+	return strings.Split(strings.Trim(b.String(), "\n"), "\n")
+}
+
+// messageLines returns the log's message history, most recent first
+func messageLines(log *MessageLog) []string {
+	history := log.Last(log.count)
+	lines := []string{"=== Messages (most recent first) ==="}
+	for i := len(history) - 1; i >= 0; i-- {
+		lines = append(lines, history[i])
+	}
+	return lines
+}
+
+// drawTUI redraws the map, status line, recent messages, and any open prompt
+func drawTUI(screen tcell.Screen, g *Game, prompt tuiPrompt, pager tuiPager) {
+	d, p := g.Dungeon, g.Player
+	screen.Clear()
+	
+	// The main menu and settings take the whole screen
+	if g.State == StateMainMenu || g.State == StateSettings {
+		lines, hint := g.MenuLines(), "Press a number to choose, or Esc to quit."
+		if g.State == StateSettings {
+			lines, hint = g.SettingsLines(), "Press a number to change a setting, or any other key to go back."
+		}
+		for i, line := range lines {
+			drawText(screen, 0, i, line)
+		}
+		drawText(screen, 0, len(lines)+1, hint)
+		screen.Show()
+		return
+	}
+	
+	// So do the help and the message history
+	if prompt == promptPager {
+		end := min(pager.top+pagerRows(screen), len(pager.lines))
+		for i, line := range pager.lines[pager.top:end] {
+			drawText(screen, 0, i, line)
+		}
+		hint := "Press any key to go back."
+		if end < len(pager.lines) {
+			hint = "Press any key for more."
+		}
+		drawText(screen, 0, end-pager.top, hint)
+		screen.Show()
+		return
+	}
+	
+	// Draw the map
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			r := d.DisplayRune(x, y, p)
+			style := tcell.StyleDefault
+			switch {
+			case r == '@':
+				style = style.Foreground(tcell.ColorYellow).Bold(true)
+			case r == fireSymbol && d.Burning(x, y):
+				style = style.Foreground(tcell.ColorOrangeRed).Bold(true)
+			case d.IsLit(x, y, p) && d.GetEnemyAt(x, y) != nil && d.GetEnemyAt(x, y).Disguised:
+			case d.IsLit(x, y, p) && d.GetEnemyAt(x, y) != nil && !d.GetEnemyAt(x, y).Hostile:
+				style = style.Foreground(tcell.ColorGreen).Bold(true)
+			case d.IsLit(x, y, p) && d.GetEnemyAt(x, y) != nil && d.GetEnemyAt(x, y).Incorporeal():
+				style = style.Foreground(tcell.ColorSilver).Dim(true)
+			case d.IsLit(x, y, p) && d.GetEnemyAt(x, y) != nil && d.GetEnemyAt(x, y).FrozenTurns > 0:
+				style = style.Foreground(tcell.ColorAqua).Bold(true)
+			case d.IsLit(x, y, p) && d.GetEnemyAt(x, y) != nil && d.GetEnemyAt(x, y).Elite:
+				style = style.Foreground(tcell.ColorFuchsia).Bold(true)
+			case d.IsLit(x, y, p) && d.GetEnemyAt(x, y) != nil:
+				style = style.Foreground(tcell.ColorRed)
+			case !d.IsLit(x, y, p):
+				style = style.Foreground(tcell.ColorGray)
+			}
+			if !g.Config.Color {
+				style = tcell.StyleDefault
+			}
+			screen.SetContent(x, y, r, nil, style)
+		}
+	}
+	
+	// Draw the status line and the latest messages below the map
+	row := d.Height
+	status := p.StatusLine(g.Turns)
+	if p.LowHealth() && g.Config.Color {
+		drawStyledText(screen, 0, row, status, tcell.StyleDefault.Foreground(tcell.ColorRed))
+		row++
+		drawStyledText(screen, 0, row, "*** LOW HEALTH ***", tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true))
+	} else if p.LowHealth() {
+		drawText(screen, 0, row, status)
+		row++
+		drawText(screen, 0, row, "*** LOW HEALTH ***")
+	} else {
+		drawText(screen, 0, row, status)
+	}
+	row++
+	drawText(screen, 0, row, d.LocationLine(p)+" | "+p.EquipmentLine())
+	for _, msg := range g.Messages.Last(5) {
+		row++
+		drawText(screen, 0, row, msg)
+	}
+	g.Messages.MarkRead()
+	
+	// Draw the open prompt, if any
+	row += 2
+	switch {
+	case g.State == StateGameOver:
+		drawText(screen, 0, row, fmt.Sprintf("%s died on dungeon level %d after %d turns. Press 'r' to restart or 'q' to quit.", p.Name, d.Level, g.Turns))
+		drawStats(screen, row+1, p.Stats)
+		drawText(screen, 0, row+1+len(p.Stats.Summary()), fmt.Sprintf("Score: %d | Seed: %s", g.Score(), g.SeedLabel()))
+	case g.State == StateVictory:
+		drawText(screen, 0, row, fmt.Sprintf("%s found the Amulet of Yendor on dungeon level %d in %d turns! Gold: %d. Press 'r' to play again or 'q' to quit.", p.Name, d.Level, g.Turns, p.Gold))
+		drawStats(screen, row+1, p.Stats)
+		drawText(screen, 0, row+1+len(p.Stats.Summary()), fmt.Sprintf("Score: %d | Seed: %s", g.Score(), g.SeedLabel()))
+	case prompt == promptLook:
+		drawText(screen, 0, row, "Look which direction?")
+	case prompt == promptCastSpell:
+		for i, spell := range p.Spells {
+			info := spells[spell]
+			drawText(screen, 0, row+i, fmt.Sprintf("%d. %s (%d mana) - %s", i+1, info.Name, info.Cost, info.Description))
+		}
+		drawText(screen, 0, row+len(p.Spells), "Cast which spell?")
+	case prompt == promptCastDirection:
+		drawText(screen, 0, row, "Cast which direction?")
+	case prompt == promptZapWand:
+		line := 0
+		for i, item := range p.Inventory {
+			if item.Type == ItemWand {
+				drawText(screen, 0, row+line, fmt.Sprintf("%d. %s", i+1, p.ItemLabel(item)))
+				line++
+			}
+		}
+		drawText(screen, 0, row+line, "Zap which wand?")
+	case prompt == promptZapDirection:
+		drawText(screen, 0, row, "Zap which direction?")
+	case prompt == promptQuit:
+		drawText(screen, 0, row, "Really quit? Unsaved progress will be lost (y/n)")
+	case prompt == promptDisarm:
+		drawText(screen, 0, row, "Disarm which direction?")
+	case prompt == promptLegend:
+		for i, line := range d.LegendLines() {
+			drawText(screen, (i/8)*24, row+i%8, line)
+		}
+	case prompt == promptDescend:
+		drawText(screen, 0, row, "Enemies are near - descend anyway? (y/n)")
+	case g.State == StateInventory:
+		drawText(screen, 0, row, p.WeaponLine())
+		drawText(screen, 0, row+1, "Worn: "+strings.Join(p.ArmorLines(), " | "))
+		drawText(screen, 0, row+2, "Accessories: "+strings.Join(p.AccessoryLines(), " | "))
+		row += 3
+		for _, line := range p.SetBonusLines() {
+			drawText(screen, 0, row, line)
+			row++
+		}
+		if len(p.Inventory) == 0 {
+			drawText(screen, 0, row, "Your inventory is empty.")
+			row++
+		}
+		if g.InventoryFilter != "" {
+			drawText(screen, 0, row, "Showing "+g.InventoryFilter+" only")
+			row++
+		}
+		for i, item := range p.Inventory {
+			if !matchesFilter(item, g.InventoryFilter) {
+				continue
+			}
+			style := rarityStyle(item.Rarity)
+			if !g.Config.Color {
+				style = tcell.StyleDefault
+			}
+			drawStyledText(screen, 0, row, fmt.Sprintf("%d. %s (%s)", i+1, p.ItemLabel(item), p.Identities.Description(item)), style)
+			row++
+		}
+		hint := "Press a number to use an item, t or d and a number to throw or drop it, o to sort, f to change the filter, any other key to close."
+		switch prompt {
+		case promptThrowItem:
+			hint = "Throw which item?"
+		case promptThrowDirection:
+			hint = "Throw which direction?"
+		case promptDropItem:
+			hint = "Drop which item?"
+		}
+		drawText(screen, 0, row, hint)
+	default:
+		drawText(screen, 0, row, "wasd/hjkl/arrows move | . wait | r rest | > descend | T travel | g pick up | f search | D disarm | x look | c cast | z zap | i inventory | H heal | F flee | n sneak | m messages | ? help | Esc menu | L legend | q quit")
+	}
+	
+	screen.Show()
+}
+
+// nextFilter returns the inventory filter after the given one, cycling
+// through every filter and back to showing all items
+func nextFilter(filter string) string {
+	names := filterNames()
+	for i, name := range names {
+		if name == filter && i+1 < len(names) {
+			return names[i+1]
+		}
+		if name == filter {
+			return "all"
+		}
+	}
+	return names[0]
+}
+
+// drawStats lists the run's statistics starting at row y
+func drawStats(screen tcell.Screen, y int, stats Stats) {
+	for i, line := range stats.Summary() {
+		drawText(screen, 0, y+i, line)
+	}
+}
+
+// rarityStyle returns the style items of the given rarity are listed in
+func rarityStyle(rarity Rarity) tcell.Style {
+	switch rarity {
+	case Uncommon:
+		return tcell.StyleDefault.Foreground(tcell.ColorGreen)
+	case Rare:
+		return tcell.StyleDefault.Foreground(tcell.ColorBlue)
+	case Epic:
+		return tcell.StyleDefault.Foreground(tcell.ColorPurple)
+	default:
+		return tcell.StyleDefault
+	}
+}
+
+// drawText writes a line of text starting at (x, y)
+func drawText(screen tcell.Screen, x, y int, text string) {
+	drawStyledText(screen, x, y, text, tcell.StyleDefault)
+}
+
+// drawStyledText writes a line of text starting at (x, y) in the given style
+func drawStyledText(screen tcell.Screen, x, y int, text string, style tcell.Style) {
+	for i, r := range text {
+		screen.SetContent(x+i, y, r, nil, style)
+	}
+}
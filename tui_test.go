@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTUIKeysMatchTextInterface(t *testing.T) {
+	for key, want := range map[string]string{"D": "disarm", "?": "help", "m": "messages"} {
+		if got := commandFor(key); got != want {
+			t.Errorf("%q is bound to %q, want %q", key, got, want)
+		}
+	}
+	if help := strings.Join(helpLines(), "\n"); !strings.Contains(help, "D - Disarm") {
+		t.Errorf("the help doesn't mention the disarm key:\n%s", help)
+	}
+}
+
+func TestMessageLinesNewestFirst(t *testing.T) {
+	log := NewMessageLog(10)
+	log.Log("older message")
+	log.Log("newer message")
+	lines := messageLines(log)
+	if len(lines) < 3 || lines[1] != "newer message" || lines[2] != "older message" {
+		t.Errorf("message lines start %q, want the header then the newest message", lines[:min(3, len(lines))])
+	}
+}
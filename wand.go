@@ -0,0 +1,116 @@
+package main
+
+import "strconv"
+
+// WandType is the magic stored in a wand
+type WandType int
+
+const (
+	WandLightning WandType = iota
+	WandSlow
+	WandFire
+)
+
+// wandInfo describes a kind of wand
+type wandInfo struct {
+	Name        string
+	Description string
+}
+
+// wands holds the details of every kind of wand
+var wands = map[WandType]wandInfo{
+	WandLightning: {"Lightning", "Deals 6 lightning damage to every enemy in a line"},
+	WandSlow:      {"Slow", "Makes the first enemy in a line act only every other turn"},
+	WandFire:      {"Fire", "Burns the first enemy in a line for 4 fire damage and sets the ground alight"},
+}
+
+// slowDuration is how many turns a wand of slow lasts
+const slowDuration = 10
+
+// NewWand creates a new wand of the given kind holding a few charges
+func NewWand(x, y int, kind WandType, charges int) Item {
+	return Item{
+		X:           x,
+		Y:           y,
+		Type:        ItemWand,
+		Name:        "Wand of " + wands[kind].Name,
+		Description: wands[kind].Description,
+		Value:       int(kind),
+		Charges:     charges,
+		Symbol:      '-',
+		Weight:      1,
+	}
+}
+
+// chargesLabel describes how many charges a wand has left
+func chargesLabel(charges int) string {
+	if charges == 1 {
+		return "1 charge"
+	}
+	return strconv.Itoa(charges) + " charges"
+}
+
+// Zap fires a wand from the inventory in the given direction, using up one of
+// its charges. It returns true if the zap took a turn.
+func (p *Player) Zap(itemIndex int, dir Direction, d *Dungeon) bool {
+	if itemIndex < 0 || itemIndex >= len(p.Inventory) {
+		p.Messages.Log("Invalid item index.")
+		return false
+	}
+	wand := &p.Inventory[itemIndex]
+	if wand.Type != ItemWand {
+		p.Messages.Log("You can't zap the %s.", p.Identities.Name(*wand))
+		return false
+	}
+	if wand.Charges == 0 {
+		p.Messages.Log("You wave the %s, but nothing happens. It is out of charges.", wand.Name)
+		return false
+	}
+	wand.Charges--
+
+	// Follow the line of the bolt until it hits a wall
+	const zapRange = 8
+	dx, dy := dir.Delta()
+	path := line(p.X, p.Y, p.X+dx*zapRange, p.Y+dy*zapRange)
+	hit := false
+	for _, pos := range path[1:] {
+		x, y := pos[0], pos[1]
+		if !d.IsWalkable(x, y) {
+			break
+		}
+		enemy := d.GetEnemyAt(x, y)
+		if enemy == nil || !enemy.Hostile {
+			continue // Bolts pass allies by
+		}
+		hit = true
+
+		switch WandType(wand.Value) {
+		case WandLightning:
+			// Lightning passes through every enemy in its path
+			damage := p.DealDamage(enemy, 6, Lightning, d)
+			p.Messages.Log("A bolt of lightning strikes %s for %d damage!", enemy.Title(), damage)
+			if enemy.Health <= 0 {
+				p.DefeatEnemy(enemy, d)
+			}
+			continue
+
+		case WandSlow:
+			d.Unmask(enemy)
+			enemy.SlowTurns = slowDuration
+			p.Messages.Log("%s slows to a crawl.", capitalize(enemy.Title()))
+
+		case WandFire:
+			damage := p.DealDamage(enemy, 4, Fire, d)
+			p.Messages.Log("A bolt of fire engulfs %s for %d damage!", enemy.Title(), damage)
+			d.Ignite(x, y, fireDuration)
+			if enemy.Health <= 0 {
+				p.DefeatEnemy(enemy, d)
+			}
+		}
+		break
+	}
+	if !hit {
+		p.Messages.Log("The bolt from the %s fizzles out.", wand.Name)
+	}
+	return true
+}
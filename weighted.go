@@ -0,0 +1,27 @@
+package main
+
+import "math/rand"
+
+// weightedPick chooses an index at random, each with odds proportional to
+// its weight. Weights below zero count as zero. It returns -1 if every
+// weight is zero.
+func weightedPick(rng *rand.Rand, weights []int) int {
+	total := 0
+	for _, w := range weights {
+		total += max(w, 0)
+	}
+	if total == 0 {
+		return -1
+	}
+	roll := rng.Intn(total)
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		if roll < w {
+			return i
+		}
+		roll -= w
+	}
+	return -1
+}